@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/bench"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+var benchCommand = &cli.Command{
+	Name:  "bench",
+	Usage: "Send orders at a target rate and report the throughput and latencies of the gateway",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "casePath",
+			Usage:    "Path to the test case file holding the order and confirm steps",
+			Required: true,
+		}, &cli.StringFlag{
+			Name:     "config",
+			Usage:    "Path to the configuration file",
+			Required: true,
+		}, &cli.StringFlag{
+			Name:     "order",
+			Usage:    "StepId of the Send step used as order template",
+			Required: true,
+		}, &cli.StringSliceFlag{
+			Name:  "oms",
+			Usage: "Simulators sending the orders in turn, every oms simulator of the configuration when not set",
+		}, &cli.StringFlag{
+			Name:  "confirm",
+			Usage: "StepId of the Send step used as confirm template, sent back by its TestTool",
+		}, &cli.StringFlag{
+			Name:  "tgw",
+			Usage: "Simulator receiving the orders when there is no confirm step",
+		}, &cli.Float64Flag{
+			Name:  "rate",
+			Usage: "Orders per second over all the oms simulators, 0 sends as fast as possible",
+		}, &cli.DurationFlag{
+			Name:  "duration",
+			Usage: "How long orders are sent",
+			Value: 10 * time.Second,
+		}, &cli.DurationFlag{
+			Name:  "drain",
+			Usage: "How long the orders in flight are waited for",
+			Value: 5 * time.Second,
+		}, &cli.StringFlag{
+			Name:  "idField",
+			Usage: "Field correlating an order with its confirm",
+			Value: "ClOrdID",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		cases, err := testcase.LoadTestCases(c.String("casePath"))
+		if err != nil {
+			return err
+		}
+		gwAutoConfig, err := config.ParseConfig(c.String("config"))
+		if err != nil {
+			return err
+		}
+		gwAutoConfig.InitConfigMap()
+		order, err := findStep(cases, c.String("order"))
+		if err != nil {
+			return err
+		}
+		opts := bench.Options{
+			Rate:     c.Float64("rate"),
+			Duration: c.Duration("duration"),
+			Drain:    c.Duration("drain"),
+			IDField:  c.String("idField"),
+			Order:    template(order),
+		}
+		tgwName := c.String("tgw")
+		if c.IsSet("confirm") {
+			confirm, err := findStep(cases, c.String("confirm"))
+			if err != nil {
+				return err
+			}
+			opts.Confirm = template(confirm)
+			tgwName = confirm.TestTool
+		}
+		var tgw tcp.Simulator[codec.BinaryCodec]
+		if tgwName != "" {
+			if tgw, err = startSimulator(gwAutoConfig, tgwName); err != nil {
+				return err
+			}
+			defer tgw.Close()
+		}
+		omsNames := c.StringSlice("oms")
+		if len(omsNames) == 0 {
+			for _, s := range gwAutoConfig.Simulators {
+				if s.Type == "oms" {
+					omsNames = append(omsNames, s.Name)
+				}
+			}
+		}
+		var oms []tcp.Simulator[codec.BinaryCodec]
+		defer func() {
+			for _, sim := range oms {
+				sim.Close()
+			}
+		}()
+		for _, name := range omsNames {
+			sim, err := startSimulator(gwAutoConfig, name)
+			if err != nil {
+				return err
+			}
+			oms = append(oms, sim)
+		}
+		report, err := bench.Run(oms, tgw, opts)
+		if err != nil {
+			return err
+		}
+		report.Print(os.Stdout)
+		return nil
+	},
+}
+
+// findStep returns the step of the cases with stepID
+func findStep(cases []*testcase.TestCase, stepID string) (*testcase.TestStep, error) {
+	for _, c := range cases {
+		for i := range c.Steps {
+			if c.Steps[i].StepID == stepID {
+				return &c.Steps[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no step %q", stepID)
+}
+
+// template returns the message sent by a Send step
+func template(step *testcase.TestStep) map[string]interface{} {
+	data := make(map[string]interface{}, len(step.TestDatas)+1)
+	for k, v := range step.TestDatas {
+		data[k] = v
+	}
+	data["MsgType"] = step.MsgType
+	return data
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/executor"
+)
+
+var diffCommand = &cli.Command{
+	Name:  "diff",
+	Usage: "Run the test cases against the two gateways of the differential configuration and compare their output",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:     "casePath",
+			Usage:    "Path to the test case file path",
+			Required: true,
+		}, &cli.StringFlag{
+			Name:     "config",
+			Usage:    "Path to the configuration file",
+			Required: true,
+		},
+	}, filterFlags...),
+	Action: func(c *cli.Context) error {
+		cases, err := loadCases(c, c.String("casePath"))
+		if err != nil {
+			return err
+		}
+		gwAutoConfig, err := config.ParseConfig(c.String("config"))
+		if err != nil {
+			return err
+		}
+		gwAutoConfig.InitConfigMap()
+		differential, err := executor.NewDifferentialExecutor(*gwAutoConfig, cases)
+		if err != nil {
+			return err
+		}
+		if !differential.Execute() {
+			return cli.Exit("the gateways diverged", 1)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+// filterFlags select the cases to run, see testcase.Filter
+var filterFlags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:  "include-tags",
+		Usage: "Only run the cases with one of these tags",
+	}, &cli.StringSliceFlag{
+		Name:  "exclude-tags",
+		Usage: "Skip the cases with one of these tags",
+	}, &cli.StringFlag{
+		Name:  "case-id",
+		Usage: "Only run the cases whose ID matches this glob, or this regular expression between slashes",
+	}, &cli.StringFlag{
+		Name:  "from-step",
+		Usage: "Start the run at this step, as case_id:step_id or a step_id of a single case, skipping the cases and steps before it",
+	},
+}
+
+// loadCases loads the cases of casePath selected by the filter flags
+func loadCases(c *cli.Context, casePath string) ([]*testcase.TestCase, error) {
+	cases, err := testcase.LoadTestCases(casePath)
+	if err != nil {
+		return nil, err
+	}
+	return testcase.Filter{
+		IncludeTags: c.StringSlice("include-tags"),
+		ExcludeTags: c.StringSlice("exclude-tags"),
+		CaseID:      c.String("case-id"),
+		FromStep:    c.String("from-step"),
+	}.Apply(cases)
+}
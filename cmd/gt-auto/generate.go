@@ -0,0 +1,65 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	"github.com/xinchentechnote/gt-auto/pkg/generate"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+var generateCommand = &cli.Command{
+	Name:  "generate",
+	Usage: "Generate test cases from a recorded capture",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "capture",
+			Usage:    "Path to the capture file recorded by a proxy simulator",
+			Required: true,
+		}, &cli.StringFlag{
+			Name:     "out",
+			Usage:    "Path to the test case file to write, data files are written next to it",
+			Required: true,
+		}, &cli.StringFlag{
+			Name:     "tool",
+			Usage:    "Name of the simulator replacing one side of the capture",
+			Required: true,
+		}, &cli.StringFlag{
+			Name:  "side",
+			Usage: "Side played by the simulator, client or server",
+			Value: capture.FromClient,
+		}, &cli.StringFlag{
+			Name:  "name",
+			Usage: "Prefix of the generated case IDs and data files",
+			Value: "generated",
+		}, &cli.StringFlag{
+			Name:  "source",
+			Usage: "Only use the messages recorded by this simulator",
+		}, &cli.StringSliceFlag{
+			Name:  "volatile",
+			Usage: "Fields whose received values are not compared",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		records, err := capture.ReadFile(c.String("capture"))
+		if err != nil {
+			return err
+		}
+		cases, err := generate.FromCapture(records, generate.Options{
+			Name:     c.String("name"),
+			Tool:     c.String("tool"),
+			Side:     c.String("side"),
+			Source:   c.String("source"),
+			Volatile: c.StringSlice("volatile"),
+		})
+		if err != nil {
+			return err
+		}
+		out := c.String("out")
+		if err := testcase.WriteCSVCases(out, cases); err != nil {
+			return err
+		}
+		log.Infof("Generated %d cases from %d messages into %s", len(cases), len(records), out)
+		return nil
+	},
+}
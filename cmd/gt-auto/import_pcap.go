@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/generate"
+	"github.com/xinchentechnote/gt-auto/pkg/pcap"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+var importPcapCommand = &cli.Command{
+	Name:  "import-pcap",
+	Usage: "Decode the TCP traffic of a pcap or pcapng file into a timeline, and optionally test cases",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "pcap",
+			Usage:    "Path to the pcap or pcapng file",
+			Required: true,
+		}, &cli.UintFlag{
+			Name:     "port",
+			Usage:    "Server port of the connections to decode",
+			Required: true,
+		}, &cli.StringFlag{
+			Name:  "protocol",
+			Usage: "Protocol of the connections",
+		}, &cli.StringFlag{
+			Name:  "config",
+			Usage: "Path to the configuration file, with --simulator instead of --protocol",
+		}, &cli.StringFlag{
+			Name:  "simulator",
+			Usage: "Simulator of the configuration whose protocol is used",
+		}, &cli.StringFlag{
+			Name:  "out",
+			Usage: "Path to write the timeline to, in the capture format",
+		}, &cli.StringFlag{
+			Name:  "cases",
+			Usage: "Path to write the test cases generated from the timeline to",
+		}, &cli.StringFlag{
+			Name:  "tool",
+			Usage: "Name of the simulator replacing one side in the generated cases",
+		}, &cli.StringFlag{
+			Name:  "side",
+			Usage: "Side played by the simulator, client or server",
+			Value: capture.FromClient,
+		}, &cli.StringFlag{
+			Name:  "name",
+			Usage: "Prefix of the generated case IDs and data files",
+			Value: "imported",
+		}, &cli.StringSliceFlag{
+			Name:  "volatile",
+			Usage: "Fields whose received values are not compared",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		simulatorConfig := config.SimulatorConfig{Protocol: c.String("protocol")}
+		if c.IsSet("config") {
+			gwAutoConfig, err := config.ParseConfig(c.String("config"))
+			if err != nil {
+				return err
+			}
+			gwAutoConfig.InitConfigMap()
+			var ok bool
+			if simulatorConfig, ok = gwAutoConfig.SimulatorMap[c.String("simulator")]; !ok {
+				return fmt.Errorf("unknown simulator: %q", c.String("simulator"))
+			}
+		} else if simulatorConfig.Protocol == "" {
+			return fmt.Errorf("either --protocol or --config and --simulator must be set")
+		}
+		framer, messageCodec, err := tcp.CreateFramerAndCodec(simulatorConfig)
+		if err != nil {
+			return err
+		}
+		path := c.String("pcap")
+		records, err := pcap.Import(path, pcap.Options{
+			Port:   uint16(c.Uint("port")),
+			Source: c.String("name"),
+			Framer: framer,
+			Codec:  messageCodec,
+		})
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if r.Error != "" {
+				fmt.Fprintf(os.Stdout, "%s  session %d  %-6s  %s  error: %s\n", r.Time.Format("15:04:05.000000"), r.Session, r.From, r.MsgType, r.Error)
+				continue
+			}
+			fmt.Fprintf(os.Stdout, "%s  session %d  %-6s  %s  %v\n", r.Time.Format("15:04:05.000000"), r.Session, r.From, r.MsgType, r.Message)
+		}
+		log.Infof("Decoded %d messages from %s", len(records), path)
+		if out := c.String("out"); out != "" {
+			if err := writeCapture(out, records); err != nil {
+				return err
+			}
+		}
+		if casePath := c.String("cases"); casePath != "" {
+			if !c.IsSet("tool") {
+				return fmt.Errorf("--tool is required to generate cases")
+			}
+			cases, err := generate.FromCapture(records, generate.Options{
+				Name:     c.String("name"),
+				Tool:     c.String("tool"),
+				Side:     c.String("side"),
+				Volatile: c.StringSlice("volatile"),
+			})
+			if err != nil {
+				return err
+			}
+			if err := testcase.WriteCSVCases(casePath, cases); err != nil {
+				return err
+			}
+			log.Infof("Generated %d cases into %s", len(cases), casePath)
+		}
+		return nil
+	},
+}
+
+func writeCapture(path string, records []capture.Record) error {
+	writer, err := capture.Create(path)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := writer.Write(r); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
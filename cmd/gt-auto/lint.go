@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/executor"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+var lintCommand = &cli.Command{
+	Name:  "lint",
+	Usage: "Check the test cases against the configuration without opening any connection",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "casePath",
+			Usage:    "Path to the test case file path",
+			Required: true,
+		}, &cli.StringFlag{
+			Name:     "config",
+			Usage:    "Path to the configuration file",
+			Required: true,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		cases, warnings, err := testcase.CheckTestCases(c.String("casePath"))
+		if err != nil {
+			return err
+		}
+		gwAutoConfig, err := config.ParseConfig(c.String("config"))
+		if err != nil {
+			return err
+		}
+		gwAutoConfig.InitConfigMap()
+		for _, warning := range warnings {
+			fmt.Println(warning)
+		}
+		issues := executor.Lint(*gwAutoConfig, cases)
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if problems := len(warnings) + len(issues); problems > 0 {
+			return cli.Exit(fmt.Sprintf("%d problems found", problems), 1)
+		}
+		fmt.Printf("%d cases checked, no problems found\n", len(cases))
+		return nil
+	},
+}
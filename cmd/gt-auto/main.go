@@ -12,7 +12,6 @@ import (
 	"github.com/urfave/cli/v2"
 	"github.com/xinchentechnote/gt-auto/pkg/config"
 	"github.com/xinchentechnote/gt-auto/pkg/executor"
-	"github.com/xinchentechnote/gt-auto/pkg/testcase"
 )
 
 func main() {
@@ -33,22 +32,41 @@ func main() {
 	app := &cli.App{
 		Name:  "gw-auto",
 		Usage: "CLI tool for gateway automation testing",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
-				Name:     "casePath",
-				Usage:    "Path to the test case file path",
-				Required: true,
+				Name:  "casePath",
+				Usage: "Path to the test case file path",
 			}, &cli.StringFlag{
-				Name:     "config",
-				Usage:    "Path to the configuration file",
-				Required: true,
+				Name:  "config",
+				Usage: "Path to the configuration file",
+			}, &cli.IntFlag{
+				Name:  "parallel",
+				Usage: "Number of workers running the cases, each with its own simulators",
+				Value: 1,
+			}, &cli.BoolFlag{
+				Name:  "fail-fast",
+				Usage: "Abort the run at the first failed step, a mismatch or a missing message included, unless the step has its own on_failure policy",
 			},
+		}, filterFlags...),
+		Commands: []*cli.Command{
+			generateCommand,
+			importPcapCommand,
+			replayCommand,
+			diffCommand,
+			benchCommand,
+			lintCommand,
 		},
 		Action: func(c *cli.Context) error {
+			// the flags are checked here, required root flags would be required by the commands too
+			for _, name := range []string{"casePath", "config"} {
+				if !c.IsSet(name) {
+					return fmt.Errorf("required flag \"%s\" not set", name)
+				}
+			}
 			// 1.Parse test cases from the provided file
 			casePath := c.String("casePath")
 			log.Info("Running test from: \n", casePath)
-			cases, err := testcase.LoadTestCases(casePath)
+			cases, err := loadCases(c, casePath)
 			if err != nil {
 				panic(err)
 			}
@@ -61,9 +79,16 @@ func main() {
 			}
 			gwAutoConfig.InitConfigMap()
 			// 3. Execute the test cases
-			executor := executor.NewCaseExecutor(*gwAutoConfig, cases)
-			// 4. Collect the results,validate and generate a report
-			executor.Execute()
+			if parallel := c.Int("parallel"); parallel > 1 {
+				if err := executor.ExecuteParallel(*gwAutoConfig, cases, parallel, c.Bool("fail-fast")); err != nil {
+					return err
+				}
+			} else {
+				executor := executor.NewCaseExecutor(*gwAutoConfig, cases)
+				executor.FailFast = c.Bool("fail-fast")
+				// 4. Collect the results,validate and generate a report
+				executor.Execute()
+			}
 			// 5. Save the report to a file
 			// 6. Print the report to the console
 			time.Sleep(time.Second * 5)
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/replay"
+)
+
+var replayCommand = &cli.Command{
+	Name:  "replay",
+	Usage: "Resend a recorded session to the gateway, answering as the recorded exchange, and compare what the gateway sends with the recording",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "capture",
+			Usage:    "Path to the capture file, recorded by a proxy simulator or imported from a pcap",
+			Required: true,
+		}, &cli.StringFlag{
+			Name:     "config",
+			Usage:    "Path to the configuration file",
+			Required: true,
+		}, &cli.StringSliceFlag{
+			Name:     "tool",
+			Usage:    "Simulator replaying the records of a capture source, as source=simulator, an oms simulator plays the client side and a tgw simulator the server side",
+			Required: true,
+		}, &cli.IntFlag{
+			Name:  "session",
+			Usage: "Only replay this session of the capture",
+		}, &cli.Float64Flag{
+			Name:  "speed",
+			Usage: "Speed factor of the recorded timing, 0 sends without waiting",
+			Value: 1,
+		}, &cli.IntFlag{
+			Name:  "timeoutMs",
+			Usage: "How long each recorded response is waited for",
+			Value: 5000,
+		}, &cli.StringSliceFlag{
+			Name:  "ignore",
+			Usage: "Fields not compared",
+		}, &cli.BoolFlag{
+			Name:  "ignoreTimes",
+			Usage: "Do not compare the fields whose name ends with Time",
+			Value: true,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		records, err := capture.ReadFile(c.String("capture"))
+		if err != nil {
+			return err
+		}
+		gwAutoConfig, err := config.ParseConfig(c.String("config"))
+		if err != nil {
+			return err
+		}
+		gwAutoConfig.InitConfigMap()
+		endpoints, err := startEndpoints(gwAutoConfig, c.StringSlice("tool"))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			for _, e := range endpoints {
+				e.Simulator.Close()
+			}
+		}()
+		report, err := replay.Replay(endpoints, records, replay.Options{
+			Session:     c.Int("session"),
+			Speed:       c.Float64("speed"),
+			Timeout:     time.Duration(c.Int("timeoutMs")) * time.Millisecond,
+			Ignore:      c.StringSlice("ignore"),
+			IgnoreTimes: c.Bool("ignoreTimes"),
+		})
+		if err != nil {
+			return err
+		}
+		report.Print(os.Stdout)
+		if !report.Passed() {
+			return cli.Exit("replay diverged from the recording", 1)
+		}
+		return nil
+	},
+}
+
+// startEndpoints starts the simulator of each source=simulator mapping, tgw simulators first
+// so that the gateway can connect to them before the oms simulators send anything
+func startEndpoints(gwAutoConfig *config.GwAutoConfig, mappings []string) ([]replay.Endpoint, error) {
+	var clients, servers []replay.Endpoint
+	var clientNames, serverNames []string
+	for _, mapping := range mappings {
+		source, name, ok := strings.Cut(mapping, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tool %q, expected source=simulator", mapping)
+		}
+		switch gwAutoConfig.SimulatorMap[name].Type {
+		case "oms":
+			clients = append(clients, replay.Endpoint{Source: source, Side: capture.FromClient})
+			clientNames = append(clientNames, name)
+		case "tgw":
+			servers = append(servers, replay.Endpoint{Source: source, Side: capture.FromServer})
+			serverNames = append(serverNames, name)
+		default:
+			return nil, fmt.Errorf("simulator %q is not an oms or tgw simulator", name)
+		}
+	}
+	endpoints := append(servers, clients...)
+	names := append(serverNames, clientNames...)
+	for i := range endpoints {
+		simulator, err := startSimulator(gwAutoConfig, names[i])
+		if err != nil {
+			for _, started := range endpoints[:i] {
+				started.Simulator.Close()
+			}
+			return nil, err
+		}
+		endpoints[i].Simulator = simulator
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+)
+
+// startSimulator creates and starts the simulator name of the configuration, waiting until it is ready
+func startSimulator(gwAutoConfig *config.GwAutoConfig, name string) (tcp.Simulator[codec.BinaryCodec], error) {
+	simulatorConfig, ok := gwAutoConfig.SimulatorMap[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown simulator: %q", name)
+	}
+	simulator, err := tcp.CreateSimulator[codec.BinaryCodec](simulatorConfig)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := simulator.Start(); err != nil {
+			log.Errorf("Simulator %s failed: %s", name, err)
+		}
+	}()
+	if !waitReady(simulator, 5*time.Second) {
+		simulator.Close()
+		return nil, fmt.Errorf("simulator %s is not connected", name)
+	}
+	return simulator, nil
+}
+
+// waitReady waits for a client simulator to connect, servers are given a second to listen
+func waitReady(simulator tcp.Simulator[codec.BinaryCodec], timeout time.Duration) bool {
+	reporter, ok := simulator.(tcp.StateReporter)
+	if !ok {
+		time.Sleep(time.Second)
+		return true
+	}
+	deadline := time.Now().Add(timeout)
+	for reporter.State() != tcp.StateConnected {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
@@ -4,8 +4,10 @@ go 1.25.5
 
 require (
 	github.com/BurntSushi/toml v1.5.0
+	github.com/bufbuild/protocompile v0.14.1
 	github.com/enriquebris/goconcurrentqueue v0.7.0
 	github.com/google/go-cmp v0.7.0
+	github.com/gorilla/websocket v1.5.3
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/sirupsen/logrus v1.9.3
 	github.com/stretchr/testify v1.11.1
@@ -15,6 +17,7 @@ require (
 	github.com/xinchentechnote/fin-proto-runtime-bin-go v0.1.0
 	github.com/xinchentechnote/fin-proto-sse-bin-go v0.57.0
 	github.com/xinchentechnote/fin-proto-szse-bin-go v1.29.0
+	google.golang.org/protobuf v1.36.12
 )
 
 require (
@@ -25,6 +28,7 @@ require (
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
 	golang.org/x/exp v0.0.0-20260312153236-7ab1446f8b90 // indirect
+	golang.org/x/sync v0.20.0 // indirect
 	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
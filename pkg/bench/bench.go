@@ -0,0 +1,321 @@
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	gt_codec "github.com/xinchentechnote/gt-auto/pkg/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+// Hops of an order, the gateway being between the oms and the tgw simulators
+const (
+	// HopInbound from the order sent by the oms to its arrival at the tgw
+	HopInbound = "oms->tgw"
+	// HopOutbound from the confirm sent by the tgw to its arrival at the oms
+	HopOutbound = "tgw->oms"
+	// HopRoundTrip from the order sent by the oms to its confirm back at the oms
+	HopRoundTrip = "round trip"
+)
+
+// hops lists the hops in the order they are reported
+var hops = []string{HopInbound, HopOutbound, HopRoundTrip}
+
+const (
+	defaultIDField = "ClOrdID"
+	defaultDrain   = 5 * time.Second
+	// pollInterval is how often an idle simulator is polled, it bounds the precision of the latencies
+	pollInterval = 100 * time.Microsecond
+)
+
+// Options of a benchmark
+// Rate, orders sent per second by all the oms simulators together, 0 sends as fast as possible
+// Duration, how long orders are sent
+// Drain, how long the orders in flight are waited for after the last one, 5s when 0
+// IDField, the field correlating an order with its arrival at the tgw and its confirm, ClOrdID when empty
+// Order, the order template with its MsgType, ${seq} is replaced by the order number, which is also
+// appended to the IDField value when it has no ${seq}
+// Confirm, the template sent back by the tgw for every order with its MsgType, ${name} is replaced by
+// the field name of the order received, nil when the tgw does not answer
+type Options struct {
+	Rate     float64
+	Duration time.Duration
+	Drain    time.Duration
+	IDField  string
+	Order    map[string]interface{}
+	Confirm  map[string]interface{}
+}
+
+// Report is the outcome of a benchmark
+// Forwarded, orders that arrived at the tgw, Confirmed, orders confirmed back at the oms
+// Elapsed, from the first order sent to the last confirm or the end of the drain
+type Report struct {
+	Sent       int
+	SendErrors int
+	Forwarded  int
+	Confirmed  int
+	Elapsed    time.Duration
+	Hops       map[string]*Histogram
+}
+
+// Throughput returns the orders confirmed per second
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Confirmed) / r.Elapsed.Seconds()
+}
+
+// inflight is an order waiting for its confirm, oms the index of the simulator that sent it
+type inflight struct {
+	oms         int
+	sent        time.Time
+	confirmSent time.Time
+}
+
+type benchmark struct {
+	opts     Options
+	oms      []tcp.Simulator[codec.BinaryCodec]
+	tgw      tcp.Simulator[codec.BinaryCodec]
+	mu       sync.Mutex
+	inflight map[string]*inflight
+	report   *Report
+	last     time.Time
+}
+
+// Run sends orders for the duration, spread in turn over the oms simulators, and correlates them
+// with their arrival at tgw, if not nil, and their confirm back at the oms simulator that sent them.
+func Run(oms []tcp.Simulator[codec.BinaryCodec], tgw tcp.Simulator[codec.BinaryCodec], opts Options) (*Report, error) {
+	if len(oms) == 0 {
+		return nil, errors.New("no oms simulator")
+	}
+	if opts.Order == nil {
+		return nil, errors.New("no order template")
+	}
+	if opts.IDField == "" {
+		opts.IDField = defaultIDField
+	}
+	if opts.Drain <= 0 {
+		opts.Drain = defaultDrain
+	}
+	if opts.Confirm != nil && tgw == nil {
+		return nil, errors.New("a confirm template needs a tgw simulator")
+	}
+	b := &benchmark{
+		opts:     opts,
+		oms:      oms,
+		tgw:      tgw,
+		inflight: make(map[string]*inflight),
+		report:   &Report{Hops: make(map[string]*Histogram)},
+	}
+	for _, hop := range hops {
+		b.report.Hops[hop] = NewHistogram()
+	}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	poll := func(receive func() (codec.BinaryCodec, string), handle func(codec.BinaryCodec, string, time.Time)) {
+		defer wg.Done()
+		for {
+			if msg, session := receive(); msg != nil {
+				handle(msg, session, time.Now())
+				continue
+			}
+			select {
+			case <-stop:
+				return
+			default:
+				time.Sleep(pollInterval)
+			}
+		}
+	}
+	for i, sim := range oms {
+		wg.Add(1)
+		receive := func() (codec.BinaryCodec, string) {
+			msg, _ := sim.Receive()
+			return msg, ""
+		}
+		go poll(receive, func(msg codec.BinaryCodec, _ string, at time.Time) { b.confirmed(i, msg, at) })
+	}
+	if tgw != nil {
+		wg.Add(1)
+		go poll(b.receiveForwarded, b.forwarded)
+	}
+	start := b.send()
+	deadline := time.Now().Add(opts.Drain)
+	for b.pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.inflight) > 0 {
+		b.report.Elapsed = time.Since(start)
+		log.Warnf("%d orders were not confirmed within %s", len(b.inflight), opts.Drain)
+	} else {
+		b.report.Elapsed = b.last.Sub(start)
+	}
+	return b.report, nil
+}
+
+// send sends the orders at the rate for the duration, it returns when the first one was sent
+func (b *benchmark) send() time.Time {
+	appendSeq := !strings.Contains(fmt.Sprint(b.opts.Order[b.opts.IDField]), "${seq}")
+	start := time.Now()
+	for n := 1; time.Since(start) < b.opts.Duration; n++ {
+		if b.opts.Rate > 0 {
+			due := start.Add(time.Duration(float64(n-1) / b.opts.Rate * float64(time.Second)))
+			time.Sleep(time.Until(due))
+		}
+		order := testcase.SubstituteVariables(b.opts.Order, map[string]string{"seq": fmt.Sprint(n)})
+		if appendSeq {
+			order[b.opts.IDField] = fmt.Sprint(order[b.opts.IDField], n)
+		}
+		id := fmt.Sprint(order[b.opts.IDField])
+		// registered first, the order may arrive before SendFromJSON returns
+		b.mu.Lock()
+		sender := (n - 1) % len(b.oms)
+		b.inflight[id] = &inflight{oms: sender, sent: time.Now()}
+		b.mu.Unlock()
+		if err := b.oms[sender].SendFromJSON(order); err != nil {
+			b.mu.Lock()
+			delete(b.inflight, id)
+			b.report.SendErrors++
+			b.mu.Unlock()
+			log.Errorf("Send failed: %s", err)
+			continue
+		}
+		b.mu.Lock()
+		b.report.Sent++
+		b.mu.Unlock()
+	}
+	return start
+}
+
+func (b *benchmark) pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.inflight)
+}
+
+// correlate returns the fields of msg and its order, nil when msg is not one of the orders in flight
+func (b *benchmark) correlate(msg codec.BinaryCodec) (map[string]interface{}, string, *inflight) {
+	fields, err := gt_codec.MessageToMap(msg)
+	if err != nil {
+		log.Warnf("Cannot correlate %T: %s", msg, err)
+		return nil, "", nil
+	}
+	value, ok := testcase.LookupField(fields, b.opts.IDField)
+	if !ok {
+		return fields, "", nil
+	}
+	id := strings.TrimSpace(fmt.Sprint(value))
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return fields, id, b.inflight[id]
+}
+
+// receiveForwarded returns the next message at the tgw and the session it arrived on,
+// the session is empty when the tgw does not track sessions
+func (b *benchmark) receiveForwarded() (codec.BinaryCodec, string) {
+	sessions, ok := b.tgw.(tcp.SessionSimulator[codec.BinaryCodec])
+	if !ok {
+		msg, _ := b.tgw.Receive()
+		return msg, ""
+	}
+	for _, info := range sessions.Sessions() {
+		if msg, _ := sessions.ReceiveFrom(info.ID); msg != nil {
+			return msg, info.ID
+		}
+	}
+	return nil, ""
+}
+
+// forwarded handles an order arriving at the tgw, answering it with the confirm template on its session
+func (b *benchmark) forwarded(msg codec.BinaryCodec, session string, at time.Time) {
+	fields, _, order := b.correlate(msg)
+	if order == nil {
+		return
+	}
+	b.mu.Lock()
+	b.report.Forwarded++
+	b.report.Hops[HopInbound].Record(at.Sub(order.sent))
+	b.mu.Unlock()
+	if b.opts.Confirm == nil {
+		return
+	}
+	variables := make(map[string]string, len(fields))
+	for k, v := range fields {
+		variables[k] = strings.TrimSpace(fmt.Sprint(v))
+	}
+	confirm := testcase.SubstituteVariables(b.opts.Confirm, variables)
+	sent := time.Now()
+	send := b.tgw.SendFromJSON
+	if session != "" {
+		send = func(message map[string]interface{}) error {
+			return b.tgw.(tcp.SessionSimulator[codec.BinaryCodec]).SendFromJSONTo(session, message)
+		}
+	}
+	if err := send(confirm); err != nil {
+		log.Errorf("Confirm failed: %s", err)
+		return
+	}
+	b.mu.Lock()
+	order.confirmSent = sent
+	b.mu.Unlock()
+}
+
+// confirmed handles the first message back at oms simulator i for an order,
+// a confirm reaching another simulator than the sender is left unconfirmed
+func (b *benchmark) confirmed(i int, msg codec.BinaryCodec, at time.Time) {
+	_, id, order := b.correlate(msg)
+	if order == nil {
+		return
+	}
+	if order.oms != i {
+		log.Warnf("Confirm of %s reached oms simulator %d instead of %d", id, i, order.oms)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.inflight, id)
+	b.report.Confirmed++
+	b.report.Hops[HopRoundTrip].Record(at.Sub(order.sent))
+	if !order.confirmSent.IsZero() {
+		b.report.Hops[HopOutbound].Record(at.Sub(order.confirmSent))
+	}
+	b.last = at
+}
+
+// Print writes the throughput and a latency table of the hops measured
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Sent %d orders (%d send errors), %d forwarded, %d confirmed in %s: %.1f orders/s\n",
+		r.Sent, r.SendErrors, r.Forwarded, r.Confirmed, r.Elapsed.Round(time.Millisecond), r.Throughput())
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Hop", "Count", "Min", "p50", "p99", "p99.9", "Max", "Mean"})
+	for _, hop := range hops {
+		h := r.Hops[hop]
+		if h.Count() == 0 {
+			continue
+		}
+		table.Append([]string{
+			hop,
+			fmt.Sprint(h.Count()),
+			h.Min().String(),
+			h.Quantile(0.5).String(),
+			h.Quantile(0.99).String(),
+			h.Quantile(0.999).String(),
+			h.Max().String(),
+			h.Mean().String(),
+		})
+	}
+	table.Render()
+}
@@ -0,0 +1,92 @@
+package bench
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+)
+
+// startTgw starts a tgw simulator on a port picked by the system and returns it with its address
+func startTgw(t *testing.T) (tcp.Simulator[codec.BinaryCodec], string) {
+	tgw, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "tgw", Protocol: "json-lines", ListenAddress: "127.0.0.1:0",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { tgw.Close() })
+	go tgw.Start()
+	reporter := tgw.(tcp.AddrReporter)
+	require.Eventually(t, func() bool { return reporter.Addr() != "" }, time.Second, 10*time.Millisecond)
+	return tgw, reporter.Addr()
+}
+
+// startOms connects n oms simulators to address
+func startOms(t *testing.T, address string, n int) []tcp.Simulator[codec.BinaryCodec] {
+	var oms []tcp.Simulator[codec.BinaryCodec]
+	for i := 0; i < n; i++ {
+		sim, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+			Type: "oms", Protocol: "json-lines", ServerAddress: address,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { sim.Close() })
+		require.NoError(t, sim.Start())
+		oms = append(oms, sim)
+	}
+	time.Sleep(100 * time.Millisecond)
+	return oms
+}
+
+func TestRun(t *testing.T) {
+	tgw, address := startTgw(t)
+	// the oms simulators talk to the tgw directly, standing for a gateway that adds no latency,
+	// each on its own session which the confirms go back on
+	oms := startOms(t, address, 2)
+
+	report, err := Run(oms, tgw, Options{
+		Rate:     200,
+		Duration: 250 * time.Millisecond,
+		Order:    map[string]interface{}{"MsgType": "NewOrder", "ClOrdID": "B${seq}", "OrderQty": "100"},
+		Confirm:  map[string]interface{}{"MsgType": "ExecutionReport", "ClOrdID": "${ClOrdID}", "OrdStatus": "0"},
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, 50, report.Sent, 2)
+	assert.Equal(t, report.Sent, report.Forwarded)
+	assert.Equal(t, report.Sent, report.Confirmed)
+	for _, hop := range hops {
+		h := report.Hops[hop]
+		assert.Equal(t, uint64(report.Sent), h.Count(), hop)
+		assert.Less(t, h.Quantile(0.5), 100*time.Millisecond, hop)
+	}
+	assert.Greater(t, report.Throughput(), 100.0)
+
+	var out bytes.Buffer
+	report.Print(&out)
+	assert.Contains(t, out.String(), "round trip")
+	assert.Contains(t, out.String(), "P99.9")
+}
+
+func TestRunWithoutConfirm(t *testing.T) {
+	tgw, address := startTgw(t)
+	oms := startOms(t, address, 1)
+
+	report, err := Run(oms, tgw, Options{
+		Duration: 50 * time.Millisecond,
+		Drain:    100 * time.Millisecond,
+		Order:    map[string]interface{}{"MsgType": "NewOrder", "ClOrdID": "A"},
+	})
+	require.NoError(t, err)
+	assert.Greater(t, report.Sent, 0)
+	assert.Zero(t, report.Confirmed)
+	assert.Equal(t, uint64(report.Forwarded), report.Hops[HopInbound].Count())
+	assert.Zero(t, report.Hops[HopRoundTrip].Count())
+
+	_, err = Run(oms, nil, Options{Order: map[string]interface{}{}, Confirm: map[string]interface{}{}})
+	assert.Error(t, err)
+	_, err = Run(nil, tgw, Options{Order: map[string]interface{}{}})
+	assert.Error(t, err)
+}
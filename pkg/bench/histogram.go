@@ -0,0 +1,110 @@
+package bench
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits sets the precision of a Histogram: values are kept within 1/2^(subBucketBits-1) of their magnitude
+const subBucketBits = 8
+
+const (
+	subBuckets     = 1 << subBucketBits
+	halfSubBuckets = subBuckets / 2
+	bucketCount    = subBuckets + (64-subBucketBits)*halfSubBuckets
+)
+
+// Histogram records durations with a constant relative precision, HDR style:
+// values below 2^subBucketBits nanoseconds are exact, larger ones are grouped in power of two buckets
+// each split in 2^(subBucketBits-1) linear sub-buckets. It is not safe for concurrent use.
+type Histogram struct {
+	counts []uint64
+	count  uint64
+	total  time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// NewHistogram creates an empty histogram
+func NewHistogram() *Histogram {
+	return &Histogram{counts: make([]uint64, bucketCount)}
+}
+
+// Record adds a duration, negative durations count as 0
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.counts[bucketIndex(uint64(d))]++
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.total += d
+}
+
+// Count returns the number of durations recorded
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+// Min returns the smallest duration recorded
+func (h *Histogram) Min() time.Duration {
+	return h.min
+}
+
+// Max returns the largest duration recorded
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+// Mean returns the average duration recorded
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.total / time.Duration(h.count)
+}
+
+// Quantile returns the duration below which a fraction q of the durations fall,
+// as the highest value of its bucket, 0.99 for the p99
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(q * float64(h.count)))
+	target = max(target, 1)
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			return min(time.Duration(bucketHighest(i)), h.max)
+		}
+	}
+	return h.max
+}
+
+// bucketIndex returns the bucket of value
+func bucketIndex(value uint64) int {
+	n := bits.Len64(value)
+	if n <= subBucketBits {
+		return int(value)
+	}
+	shift := n - subBucketBits
+	sub := value >> shift
+	return subBuckets + (shift-1)*halfSubBuckets + int(sub-halfSubBuckets)
+}
+
+// bucketHighest returns the largest value of bucket i
+func bucketHighest(i int) uint64 {
+	if i < subBuckets {
+		return uint64(i)
+	}
+	shift := (i-subBuckets)/halfSubBuckets + 1
+	sub := uint64((i-subBuckets)%halfSubBuckets + halfSubBuckets)
+	return (sub+1)<<shift - 1
+}
@@ -0,0 +1,41 @@
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramQuantiles(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 10000; i++ {
+		h.Record(time.Duration(i) * time.Microsecond)
+	}
+	assert.Equal(t, uint64(10000), h.Count())
+	assert.Equal(t, time.Microsecond, h.Min())
+	assert.Equal(t, 10*time.Millisecond, h.Max())
+	assert.Equal(t, 5000500*time.Nanosecond, h.Mean())
+	for q, expected := range map[float64]time.Duration{
+		0.5:   5 * time.Millisecond,
+		0.99:  9900 * time.Microsecond,
+		0.999: 9990 * time.Microsecond,
+		1:     10 * time.Millisecond,
+	} {
+		actual := h.Quantile(q)
+		assert.GreaterOrEqual(t, actual, expected, q)
+		assert.InEpsilon(t, float64(expected), float64(actual), 0.01, q)
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	for _, v := range []uint64{0, 1, 255, 256, 257, 1000, 123456789, 1 << 40, 1<<63 - 1} {
+		i := bucketIndex(v)
+		assert.Less(t, i, bucketCount)
+		assert.GreaterOrEqual(t, bucketHighest(i), v)
+		if i > 0 {
+			assert.Less(t, bucketHighest(i-1), v)
+		}
+	}
+	assert.Zero(t, NewHistogram().Quantile(0.99))
+}
@@ -0,0 +1,121 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	fin_codec "github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/codec"
+)
+
+const (
+	// FromClient the message was sent by the side that connected
+	FromClient = "client"
+	// FromServer the message was sent by the side that accepted the connection
+	FromServer = "server"
+)
+
+// Record is one message of a capture file, a capture file holds one JSON record per line.
+// Source, the name of the proxy or simulator that saw the message
+// Session, the connection the message belongs to, starting at 1
+// From, FromClient or FromServer
+// Message, the decoded message as a JSON-like map, empty when it could not be decoded
+// Raw, the frame as read from the connection
+// Error, why the frame could not be decoded or failed its checksum
+type Record struct {
+	Time     time.Time              `json:"time"`
+	Source   string                 `json:"source"`
+	Session  int                    `json:"session"`
+	From     string                 `json:"from"`
+	Protocol string                 `json:"protocol"`
+	MsgType  string                 `json:"msg_type"`
+	Message  map[string]interface{} `json:"message,omitempty"`
+	Raw      []byte                 `json:"raw"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// Decode decodes a frame into a record, fault is why the frame failed its checksum if it did.
+// The decoded message is returned too, nil when the frame could not be decoded.
+// Time, Source, Session and From are left to the caller.
+func Decode(c codec.MessageCodec, data []byte, fault error) (Record, fin_codec.BinaryCodec) {
+	record := Record{
+		Protocol: c.ProtoName(),
+		Raw:      data,
+	}
+	msgType, msg, err := c.Decode(data)
+	if err != nil {
+		record.Error = err.Error()
+		return record, nil
+	}
+	if fault != nil {
+		record.Error = fault.Error()
+	}
+	if msgType != nil {
+		record.MsgType = fmt.Sprint(msgType)
+	}
+	if record.Message, err = codec.MessageToMap(msg); err != nil {
+		log.Printf("Cannot convert %T: %v", msg, err)
+	}
+	return record, msg
+}
+
+// Writer appends records to a capture file, it is safe for concurrent use.
+type Writer struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// Create creates or truncates the capture file at path
+func Create(path string) (*Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create capture file: %w", err)
+	}
+	return &Writer{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// Write appends a record
+func (w *Writer) Write(record Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.encoder.Encode(record)
+}
+
+// Close closes the capture file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// ReadFile reads every record of the capture file at path
+func ReadFile(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		decoder.UseNumber()
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,61 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ChecksumError is returned by a ChecksumFramer when the checksum trailer of a
+// frame does not match the checksum computed over its header and body.
+// The frame itself is still returned alongside the error so it can be decoded.
+type ChecksumError struct {
+	// Expected is the checksum computed over the received header and body.
+	Expected uint32
+	// Actual is the checksum carried in the frame trailer.
+	Actual uint32
+}
+
+// Error implements error.
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected %d, actual %d", e.Expected, e.Actual)
+}
+
+// ChecksumFramer is implemented by framers whose frames end with a 4-byte checksum trailer.
+type ChecksumFramer interface {
+	Framer
+	// VerifyChecksum checks the trailer of a complete frame and returns a *ChecksumError on mismatch.
+	VerifyChecksum(frame []byte) error
+	// CorruptChecksum overwrites the trailer of a complete frame with a wrong checksum.
+	CorruptChecksum(frame []byte)
+}
+
+// GenerateCheckSum computes the exchange checksum: the sum of all bytes modulo 256.
+// Both the SSE and SZSE binary protocols use this algorithm over header and body.
+func GenerateCheckSum(data []byte) uint32 {
+	var sum uint32
+	for _, b := range data {
+		sum += uint32(b)
+	}
+	return sum % 256
+}
+
+func verifyTrailerChecksum(frame []byte) error {
+	if len(frame) < 4 {
+		return ErrInvalidPacket
+	}
+	expected := GenerateCheckSum(frame[:len(frame)-4])
+	actual := binary.BigEndian.Uint32(frame[len(frame)-4:])
+	if expected != actual {
+		return &ChecksumError{Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+func corruptTrailerChecksum(frame []byte) {
+	if len(frame) < 4 {
+		return
+	}
+	// any value outside [0, 256) can never be a valid checksum
+	expected := GenerateCheckSum(frame[:len(frame)-4])
+	binary.BigEndian.PutUint32(frame[len(frame)-4:], expected+256)
+}
@@ -0,0 +1,50 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func szseFrame(body []byte) []byte {
+	frame := make([]byte, 8, 8+len(body)+4)
+	binary.BigEndian.PutUint32(frame[0:4], 100101)
+	binary.BigEndian.PutUint32(frame[4:8], uint32(len(body)))
+	frame = append(frame, body...)
+	return binary.BigEndian.AppendUint32(frame, GenerateCheckSum(frame))
+}
+
+func TestGenerateCheckSum(t *testing.T) {
+	assert.Equal(t, uint32(0), GenerateCheckSum(nil))
+	assert.Equal(t, uint32(6), GenerateCheckSum([]byte{1, 2, 3}))
+	assert.Equal(t, uint32(44), GenerateCheckSum([]byte{200, 100}))
+}
+
+func TestSzseBinFramer_ReadFrameChecksum(t *testing.T) {
+	framer := &SzseBinFramer{}
+	good := szseFrame([]byte("hello"))
+	bad := szseFrame([]byte("world"))
+	framer.CorruptChecksum(bad)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go func() {
+		client.Write(good)
+		client.Write(bad)
+	}()
+
+	frame, err := framer.ReadFrame(server)
+	assert.NoError(t, err)
+	assert.Equal(t, good, frame)
+
+	frame, err = framer.ReadFrame(server)
+	var checksumErr *ChecksumError
+	assert.True(t, errors.As(err, &checksumErr))
+	assert.Equal(t, bad, frame)
+	assert.Equal(t, GenerateCheckSum(bad[:len(bad)-4]), checksumErr.Expected)
+	assert.Equal(t, checksumErr.Expected+256, checksumErr.Actual)
+}
@@ -0,0 +1,89 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+)
+
+// httpReservedKeys are the request and response parts of an HTTP message, any other key
+// is a field of the JSON body.
+var httpReservedKeys = map[string]bool{"Method": true, "Path": true, "Headers": true, "Status": true, "Body": true}
+
+// HTTPMessageCodec converts TestDatas to the JSONMessage exchanged by the http simulators.
+// Method, Path, Headers, Status and Body are reserved keys, the remaining keys form the body
+// when Body is not given. MsgType and StepId only name the step and are dropped.
+type HTTPMessageCodec struct{}
+
+// ProtoName implements MessageCodec.
+func (c *HTTPMessageCodec) ProtoName() string {
+	return HTTP
+}
+
+// EncodeJSONMap implements MessageCodec.
+func (c *HTTPMessageCodec) EncodeJSONMap(message map[string]interface{}) ([]byte, error) {
+	data, err := c.JSONToStruct(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+	return c.Encode(nil, data)
+}
+
+// JSONToStruct implements MessageCodec.
+// Values written as JSON objects or arrays in a string are decoded, so CSV cells can hold a body.
+func (c *HTTPMessageCodec) JSONToStruct(jsonMap map[string]interface{}) (codec.BinaryCodec, error) {
+	message := make(JSONMessage)
+	body := make(map[string]interface{})
+	for k, v := range jsonMap {
+		if k == "StepId" || k == "MsgType" {
+			continue
+		}
+		if s, ok := v.(string); ok && (strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")) {
+			v = decodeJSONString(s)
+		}
+		if httpReservedKeys[k] {
+			message[k] = v
+		} else {
+			body[k] = v
+		}
+	}
+	if _, ok := message["Body"]; !ok && len(body) > 0 {
+		message["Body"] = body
+	}
+	return &message, nil
+}
+
+// Encode implements MessageCodec.
+func (c *HTTPMessageCodec) Encode(_ interface{}, message codec.BinaryCodec) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := message.Encode(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode implements MessageCodec, it returns the Path field if any.
+func (c *HTTPMessageCodec) Decode(data []byte) (interface{}, codec.BinaryCodec, error) {
+	var message JSONMessage
+	if err := message.Decode(bytes.NewBuffer(data)); err != nil {
+		return nil, nil, err
+	}
+	return message["Path"], &message, nil
+}
+
+// DecodeHTTPBody decodes a request or response body, a body that is not JSON is kept as text.
+func DecodeHTTPBody(data []byte) interface{} {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return string(data)
+	}
+	return v
+}
@@ -0,0 +1,141 @@
+package codec
+
+import "strconv"
+
+// imixTags maps IMIX field names to tags.
+// IMIX is based on FIX 4.4, so the standard FIX tags apply; fields missing here
+// can still be used by writing the tag number as the key.
+var imixTags = map[string]int{
+	"Account":                    1,
+	"AvgPx":                      6,
+	"BeginString":                8,
+	"BodyLength":                 9,
+	"CheckSum":                   10,
+	"ClOrdID":                    11,
+	"CumQty":                     14,
+	"Currency":                   15,
+	"ExecID":                     17,
+	"SecurityIDSource":           22,
+	"LastPx":                     31,
+	"LastQty":                    32,
+	"MsgSeqNum":                  34,
+	"MsgType":                    35,
+	"OrderID":                    37,
+	"OrderQty":                   38,
+	"OrdStatus":                  39,
+	"OrdType":                    40,
+	"OrigClOrdID":                41,
+	"PossDupFlag":                43,
+	"Price":                      44,
+	"RefSeqNum":                  45,
+	"SecurityID":                 48,
+	"SenderCompID":               49,
+	"SenderSubID":                50,
+	"SendingTime":                52,
+	"Side":                       54,
+	"Symbol":                     55,
+	"TargetCompID":               56,
+	"TargetSubID":                57,
+	"Text":                       58,
+	"TimeInForce":                59,
+	"TransactTime":               60,
+	"SettlType":                  63,
+	"SettlDate":                  64,
+	"TradeDate":                  75,
+	"PossResend":                 97,
+	"EncryptMethod":              98,
+	"HeartBtInt":                 108,
+	"OnBehalfOfCompID":           115,
+	"QuoteID":                    117,
+	"SettlCurrAmt":               119,
+	"OrigSendingTime":            122,
+	"DeliverToCompID":            128,
+	"QuoteReqID":                 131,
+	"ExecType":                   150,
+	"LeavesQty":                  151,
+	"AccruedInterestAmt":         159,
+	"SettlDate2":                 193,
+	"SecondaryOrderID":           198,
+	"RepurchaseTerm":             226,
+	"RepurchaseRate":             227,
+	"NoStipulations":             232,
+	"StipulationType":            233,
+	"StipulationValue":           234,
+	"Yield":                      236,
+	"UnderlyingSecurityIDSource": 305,
+	"UnderlyingSecurityID":       309,
+	"UnderlyingSymbol":           311,
+	"PartyIDSource":              447,
+	"PartyID":                    448,
+	"PartyRole":                  452,
+	"NoPartyIDs":                 453,
+	"NoSecurityAltID":            454,
+	"SecurityAltID":              455,
+	"SecurityAltIDSource":        456,
+	"PartySubID":                 523,
+	"NoLegs":                     555,
+	"LegPrice":                   566,
+	"TradeReportID":              571,
+	"LegSettlType":               587,
+	"LegSettlDate":               588,
+	"LegSymbol":                  600,
+	"LegSecurityID":              602,
+	"LegSide":                    624,
+	"Price2":                     640,
+	"LegOrderQty":                685,
+	"NoUnderlyings":              711,
+	"NoPartySubIDs":              802,
+	"PartySubIDType":             803,
+	"UnderlyingPx":               810,
+	"UnderlyingQty":              879,
+	"TradeID":                    1003,
+}
+
+// imixGroups lists the member tags of each repeating group by its counter tag.
+// Members are written in this order, the first one should start every entry.
+var imixGroups = map[int][]int{
+	// parties
+	453: {448, 447, 452, 802},
+	802: {523, 803},
+	// bond stipulations, e.g. yield type or clean price
+	232: {233, 234},
+	454: {455, 456},
+	// repo collateral bonds
+	711: {309, 305, 311, 879, 810},
+	// legs of repo and bond forward trades
+	555: {600, 602, 624, 566, 685, 587, 588},
+}
+
+// imixHeaderTags are written right after MsgType, in this order.
+var imixHeaderTags = []int{49, 56, 115, 128, 50, 57, 34, 43, 97, 52, 122}
+
+// imixSessionTags are maintained by the codec and never exposed as message fields.
+var imixSessionTags = map[int]bool{8: true, 9: true, 10: true, 34: true, 35: true, 52: true}
+
+var imixNames = func() map[int]string {
+	names := make(map[int]string, len(imixTags))
+	for name, tag := range imixTags {
+		names[tag] = name
+	}
+	return names
+}()
+
+// imixFieldKey resolves a field name or a tag number written as a string.
+func imixFieldKey(key string) (int, bool) {
+	if tag, ok := imixTags[key]; ok {
+		return tag, true
+	}
+	tag, err := strconv.Atoi(key)
+	if err != nil || tag <= 0 {
+		return 0, false
+	}
+	return tag, true
+}
+
+// imixFieldName returns the field name of tag, or the tag number when it is not in the dictionary.
+func imixFieldName(tag int) string {
+	if name, ok := imixNames[tag]; ok {
+		return name
+	}
+	return strconv.Itoa(tag)
+}
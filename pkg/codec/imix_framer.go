@@ -0,0 +1,93 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// imixTrailerLen is the length of the "10=nnn\x01" checksum trailer
+const imixTrailerLen = 7
+
+// ImixFramer is a framer for the IMIX tag=value protocol.
+type ImixFramer struct{}
+
+// ProtoName implements Framer.
+func (r *ImixFramer) ProtoName() string {
+	return IMIX
+}
+
+// ReadFrame implements Framer.
+// If the checksum trailer is wrong the frame is returned together with a *ChecksumError.
+func (r *ImixFramer) ReadFrame(conn net.Conn) ([]byte, error) {
+	begin, err := readImixField(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive message: %w", err)
+	}
+	length, err := readImixField(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive message: %w", err)
+	}
+	if !bytes.HasPrefix(begin, []byte("8=")) || !bytes.HasPrefix(length, []byte("9=")) {
+		return nil, fmt.Errorf("missing BeginString or BodyLength: %w", ErrInvalidPacket)
+	}
+	bodyLen, err := strconv.Atoi(string(length[2 : len(length)-1]))
+	if err != nil || bodyLen < 0 {
+		return nil, fmt.Errorf("bad BodyLength %q: %w", length, ErrInvalidPacket)
+	}
+	//body + checksum trailer
+	body := make([]byte, bodyLen+imixTrailerLen)
+	_, err = io.ReadFull(conn, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive message: %w", err)
+	}
+	frame := append(append(begin, length...), body...)
+	return frame, r.VerifyChecksum(frame)
+}
+
+// readImixField reads one tag=value field including its delimiter
+func readImixField(conn net.Conn) ([]byte, error) {
+	field := make([]byte, 0, 16)
+	b := make([]byte, 1)
+	for len(field) < 64 {
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return nil, err
+		}
+		field = append(field, b[0])
+		if b[0] == imixSOH {
+			return field, nil
+		}
+	}
+	return nil, fmt.Errorf("field too long: %w", ErrInvalidPacket)
+}
+
+// VerifyChecksum implements ChecksumFramer.
+func (r *ImixFramer) VerifyChecksum(frame []byte) error {
+	if len(frame) < imixTrailerLen {
+		return ErrInvalidPacket
+	}
+	trailer := frame[len(frame)-imixTrailerLen:]
+	if !bytes.HasPrefix(trailer, []byte("10=")) || trailer[imixTrailerLen-1] != imixSOH {
+		return fmt.Errorf("missing CheckSum: %w", ErrInvalidPacket)
+	}
+	actual, err := strconv.Atoi(string(trailer[3:6]))
+	if err != nil {
+		return fmt.Errorf("bad CheckSum %q: %w", trailer, ErrInvalidPacket)
+	}
+	expected := GenerateCheckSum(frame[:len(frame)-imixTrailerLen])
+	if expected != uint32(actual) {
+		return &ChecksumError{Expected: expected, Actual: uint32(actual)}
+	}
+	return nil
+}
+
+// CorruptChecksum implements ChecksumFramer.
+func (r *ImixFramer) CorruptChecksum(frame []byte) {
+	if len(frame) < imixTrailerLen {
+		return
+	}
+	expected := GenerateCheckSum(frame[:len(frame)-imixTrailerLen])
+	copy(frame[len(frame)-imixTrailerLen+3:], fmt.Sprintf("%03d", (expected+1)%256))
+}
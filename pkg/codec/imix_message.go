@@ -0,0 +1,271 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// imixSOH is the field delimiter of tag=value messages
+const imixSOH = '\x01'
+
+// ImixMessage is an IMIX tag=value message.
+// Fields are keyed by field name, or by tag number for fields outside the dictionary.
+// A repeating group is stored under its counter field as a list of field maps.
+// BeginString, BodyLength, CheckSum, MsgSeqNum and SendingTime are maintained by the codec.
+type ImixMessage struct {
+	MsgType string
+	Fields  map[string]interface{}
+}
+
+type imixField struct {
+	tag   int
+	value string
+}
+
+// Encode implements codec.BinaryCodec, it writes MsgType, header and body fields.
+func (m *ImixMessage) Encode(buf *bytes.Buffer) error {
+	return writeImixMessage(buf, m, nil)
+}
+
+// Decode implements codec.BinaryCodec, session fields in buf are skipped.
+func (m *ImixMessage) Decode(buf *bytes.Buffer) error {
+	data := buf.Bytes()
+	buf.Reset()
+	var fields []imixField
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, imixSOH)
+		if end < 0 {
+			return fmt.Errorf("unterminated field: %q: %w", data, ErrInvalidPacket)
+		}
+		eq := bytes.IndexByte(data[:end], '=')
+		if eq <= 0 {
+			return fmt.Errorf("malformed field: %q: %w", data[:end], ErrInvalidPacket)
+		}
+		tag, err := strconv.Atoi(string(data[:eq]))
+		if err != nil {
+			return fmt.Errorf("malformed tag: %q: %w", data[:eq], ErrInvalidPacket)
+		}
+		fields = append(fields, imixField{tag: tag, value: string(data[eq+1 : end])})
+		data = data[end+1:]
+	}
+	p := &imixParser{fields: fields}
+	m.Fields = make(map[string]interface{})
+	for p.pos < len(p.fields) {
+		f := p.fields[p.pos]
+		p.pos++
+		if f.tag == 35 {
+			m.MsgType = f.value
+		}
+		if err := p.set(m.Fields, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the message in tag=value form with '|' as delimiter.
+func (m *ImixMessage) String() string {
+	var buf bytes.Buffer
+	if err := m.Encode(&buf); err != nil {
+		return fmt.Sprintf("%s %v", m.MsgType, m.Fields)
+	}
+	return string(bytes.ReplaceAll(buf.Bytes(), []byte{imixSOH}, []byte{'|'}))
+}
+
+type imixParser struct {
+	fields []imixField
+	pos    int
+}
+
+// set stores f into fields, reading the entries that follow a group counter
+func (p *imixParser) set(fields map[string]interface{}, f imixField) error {
+	if imixSessionTags[f.tag] {
+		return nil
+	}
+	if _, ok := imixGroups[f.tag]; !ok {
+		fields[imixFieldName(f.tag)] = f.value
+		return nil
+	}
+	n, err := strconv.Atoi(f.value)
+	if err != nil {
+		return fmt.Errorf("group %s: bad count %q: %w", imixFieldName(f.tag), f.value, ErrInvalidPacket)
+	}
+	entries, err := p.group(f.tag, n)
+	if err != nil {
+		return err
+	}
+	fields[imixFieldName(f.tag)] = entries
+	return nil
+}
+
+// group reads n entries, an entry ends at a tag outside the group or a tag it already holds
+func (p *imixParser) group(counter int, n int) ([]interface{}, error) {
+	members := imixGroups[counter]
+	entries := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		if p.pos >= len(p.fields) || !containsTag(members, p.fields[p.pos].tag) {
+			return nil, fmt.Errorf("group %s: missing entry %d: %w", imixFieldName(counter), i, ErrInvalidPacket)
+		}
+		entry := make(map[string]interface{})
+		for p.pos < len(p.fields) {
+			f := p.fields[p.pos]
+			if _, seen := entry[imixFieldName(f.tag)]; seen || !containsTag(members, f.tag) {
+				break
+			}
+			p.pos++
+			if err := p.set(entry, f); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func containsTag(tags []int, tag int) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeImixMessage writes MsgType followed by the header and body fields,
+// session carries the codec maintained header fields such as MsgSeqNum.
+func writeImixMessage(buf *bytes.Buffer, m *ImixMessage, session map[int]string) error {
+	if m.MsgType == "" {
+		return fmt.Errorf("missing MsgType")
+	}
+	writeImixField(buf, 35, m.MsgType)
+	fields := make(map[string]interface{}, len(m.Fields)+len(session))
+	for k, v := range m.Fields {
+		fields[k] = v
+	}
+	for tag, v := range session {
+		fields[strconv.Itoa(tag)] = v
+	}
+	return writeImixFields(buf, fields, imixHeaderTags, session)
+}
+
+// writeImixFields writes fields, those listed in order first and the rest by tag
+func writeImixFields(buf *bytes.Buffer, fields map[string]interface{}, order []int, session map[int]string) error {
+	type entry struct {
+		tag   int
+		value interface{}
+	}
+	entries := make([]entry, 0, len(fields))
+	for k, v := range fields {
+		tag, ok := imixFieldKey(k)
+		if !ok {
+			return fmt.Errorf("unknown field: %s", k)
+		}
+		if _, ok := session[tag]; imixSessionTags[tag] && !ok {
+			continue
+		}
+		entries = append(entries, entry{tag: tag, value: v})
+	}
+	rank := func(tag int) int {
+		for i, t := range order {
+			if t == tag {
+				return i - len(order)
+			}
+		}
+		return tag
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return rank(entries[i].tag) < rank(entries[j].tag)
+	})
+	for _, e := range entries {
+		members, isGroup := imixGroups[e.tag]
+		if !isGroup {
+			value, err := imixValue(e.value)
+			if err != nil {
+				return fmt.Errorf("field '%s': %w", imixFieldName(e.tag), err)
+			}
+			writeImixField(buf, e.tag, value)
+			continue
+		}
+		items, ok := e.value.([]interface{})
+		if !ok {
+			return fmt.Errorf("group '%s': cannot convert %T to list", imixFieldName(e.tag), e.value)
+		}
+		writeImixField(buf, e.tag, strconv.Itoa(len(items)))
+		for _, item := range items {
+			itemFields, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("group '%s': cannot convert %T to entry", imixFieldName(e.tag), item)
+			}
+			if err := writeImixFields(buf, itemFields, members, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeImixField(buf *bytes.Buffer, tag int, value string) {
+	buf.WriteString(strconv.Itoa(tag))
+	buf.WriteByte('=')
+	buf.WriteString(value)
+	buf.WriteByte(imixSOH)
+}
+
+func imixValue(raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case bool:
+		if v {
+			return "Y", nil
+		}
+		return "N", nil
+	default:
+		return "", fmt.Errorf("cannot convert %T to field value", raw)
+	}
+}
+
+// normalizeImixFields converts a JSON-like map to ImixMessage fields.
+// Keys that are neither field names nor tag numbers, such as StepId, are ignored.
+func normalizeImixFields(data map[string]interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	for k, raw := range data {
+		tag, ok := imixFieldKey(k)
+		if !ok || imixSessionTags[tag] {
+			continue
+		}
+		name := imixFieldName(tag)
+		if _, isGroup := imixGroups[tag]; !isGroup {
+			value, err := imixValue(raw)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s': %w", name, err)
+			}
+			fields[name] = value
+			continue
+		}
+		items, ok := decodeJSONString(raw).([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("group '%s': cannot convert %T to list", name, raw)
+		}
+		entries := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			itemFields, ok := decodeJSONString(item).(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("group '%s': cannot convert %T to entry", name, item)
+			}
+			entry, err := normalizeImixFields(itemFields)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+		fields[name] = entries
+	}
+	return fields, nil
+}
@@ -0,0 +1,74 @@
+package codec
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+func TestImixMessageCodec_EncodeDecode(t *testing.T) {
+	c := &ImixMessageCodec{}
+	message := map[string]interface{}{
+		"StepId":       "repo_001",
+		"MsgType":      "AE",
+		"SenderCompID": "CFETS",
+		"TargetCompID": "GW01",
+		"TradeID":      "T0001",
+		"Side":         "1",
+		"Price":        "100.25",
+		"10176":        "4",
+		"NoPartyIDs":   `[{"PartyID":"B001","PartyRole":"1","NoPartySubIDs":[{"PartySubID":"Trader1","PartySubIDType":"101"}]},{"PartyID":"B002","PartyRole":"17"}]`,
+		"NoUnderlyings": []interface{}{
+			map[string]interface{}{"UnderlyingSecurityID": "190210", "UnderlyingQty": float64(1000)},
+		},
+	}
+	encoded, err := c.EncodeJSONMap(message)
+	require.NoError(t, err)
+	wire := strings.ReplaceAll(string(encoded), "\x01", "|")
+	assert.True(t, strings.HasPrefix(wire, "8=IMIX.1.0|9="), wire)
+	assert.Contains(t, wire, "|35=AE|49=CFETS|56=GW01|34=1|52=")
+	assert.Contains(t, wire, "|453=2|448=B001|452=1|802=1|523=Trader1|803=101|448=B002|452=17|")
+	assert.Contains(t, wire, "|711=1|309=190210|879=1000|")
+
+	framer := &ImixFramer{}
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go client.Write(encoded)
+	frame, err := framer.ReadFrame(server)
+	require.NoError(t, err)
+	assert.Equal(t, encoded, frame)
+
+	msgType, decoded, err := c.Decode(frame)
+	require.NoError(t, err)
+	assert.Equal(t, "AE", msgType)
+
+	expect, err := c.JSONToStruct(message)
+	require.NoError(t, err)
+	result := validate.CompareStruct(expect, decoded)
+	assert.True(t, result.Equal, result.Diffs)
+
+	message["NoPartyIDs"] = `[{"PartyID":"B001","PartyRole":"1"}]`
+	expect, err = c.JSONToStruct(message)
+	require.NoError(t, err)
+	result = validate.CompareStruct(expect, decoded)
+	assert.False(t, result.Equal)
+}
+
+func TestImixFramer_Checksum(t *testing.T) {
+	c := &ImixMessageCodec{}
+	encoded, err := c.EncodeJSONMap(map[string]interface{}{"MsgType": "0"})
+	require.NoError(t, err)
+
+	framer := &ImixFramer{}
+	assert.NoError(t, framer.VerifyChecksum(encoded))
+	framer.CorruptChecksum(encoded)
+	var checksumErr *ChecksumError
+	assert.True(t, errors.As(framer.VerifyChecksum(encoded), &checksumErr))
+	assert.Equal(t, (checksumErr.Expected+1)%256, checksumErr.Actual)
+}
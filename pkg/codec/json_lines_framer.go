@@ -0,0 +1,75 @@
+package codec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// maxJSONLineLen guards against peers that never send a newline
+const maxJSONLineLen = 4 << 20
+
+// JSONLinesFramer is a framer for newline-delimited JSON, one message per line.
+// Reads are buffered per connection, so one framer can serve several connections.
+type JSONLinesFramer struct {
+	mu      sync.Mutex
+	readers map[net.Conn]*bufio.Reader
+}
+
+// ProtoName implements Framer.
+func (r *JSONLinesFramer) ProtoName() string {
+	return JSONLines
+}
+
+// ReadFrame implements Framer.
+// The returned frame includes the trailing newline, empty lines are skipped.
+func (r *JSONLinesFramer) ReadFrame(conn net.Conn) ([]byte, error) {
+	reader := r.reader(conn)
+	var line []byte
+	for len(line) < maxJSONLineLen {
+		b, err := reader.ReadByte()
+		if err != nil {
+			r.release(conn)
+			return nil, fmt.Errorf("failed to receive message: %w", err)
+		}
+		if b != '\n' {
+			line = append(line, b)
+			continue
+		}
+		if len(line) == 0 || (len(line) == 1 && line[0] == '\r') {
+			line = line[:0]
+			continue
+		}
+		return append(line, '\n'), nil
+	}
+	r.release(conn)
+	return nil, fmt.Errorf("line exceeds %d bytes: %w", maxJSONLineLen, ErrInvalidPacket)
+}
+
+// reader returns conn itself when it can already read single bytes cheaply, and the
+// buffered reader of conn otherwise, the buffer keeps the bytes read past a line
+func (r *JSONLinesFramer) reader(conn net.Conn) io.ByteReader {
+	if br, ok := conn.(io.ByteReader); ok {
+		return br
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.readers == nil {
+		r.readers = make(map[net.Conn]*bufio.Reader)
+	}
+	br, ok := r.readers[conn]
+	if !ok {
+		br = bufio.NewReader(conn)
+		r.readers[conn] = br
+	}
+	return br
+}
+
+// release forgets the buffered reader of a connection, the session ends on any read error
+func (r *JSONLinesFramer) release(conn net.Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.readers, conn)
+}
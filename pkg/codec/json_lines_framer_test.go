@@ -0,0 +1,28 @@
+package codec
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONLinesFramer_ReadFrame(t *testing.T) {
+	framer := &JSONLinesFramer{}
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		client.Write([]byte("{\"a\":1}\n\r\n{\"b\":2}\r\n{\"c\""))
+		client.Write([]byte(":3}\n"))
+		client.Close()
+	}()
+
+	for _, want := range []string{"{\"a\":1}\n", "{\"b\":2}\r\n", "{\"c\":3}\n"} {
+		frame, err := framer.ReadFrame(server)
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(frame))
+	}
+	_, err := framer.ReadFrame(server)
+	assert.Error(t, err)
+	assert.Empty(t, framer.readers, "the reader of a closed connection is released")
+}
@@ -0,0 +1,91 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+)
+
+// JSONMessage is a JSON object message, numbers are kept as json.Number when decoded.
+type JSONMessage map[string]interface{}
+
+// Encode implements codec.BinaryCodec.
+func (m *JSONMessage) Encode(buf *bytes.Buffer) error {
+	return json.NewEncoder(buf).Encode(map[string]interface{}(*m))
+}
+
+// Decode implements codec.BinaryCodec.
+func (m *JSONMessage) Decode(buf *bytes.Buffer) error {
+	decoder := json.NewDecoder(buf)
+	decoder.UseNumber()
+	fields := make(map[string]interface{})
+	if err := decoder.Decode(&fields); err != nil {
+		return err
+	}
+	*m = fields
+	return nil
+}
+
+// String returns the message as compact JSON.
+func (m *JSONMessage) String() string {
+	data, err := json.Marshal(map[string]interface{}(*m))
+	if err != nil {
+		return fmt.Sprintf("%v", map[string]interface{}(*m))
+	}
+	return string(data)
+}
+
+// JSONLinesMessageCodec is a codec for newline-delimited JSON objects.
+// TestDatas are sent as-is except StepId, MsgType is only sent when it is not empty.
+type JSONLinesMessageCodec struct{}
+
+// ProtoName implements MessageCodec.
+func (c *JSONLinesMessageCodec) ProtoName() string {
+	return JSONLines
+}
+
+// EncodeJSONMap implements MessageCodec.
+func (c *JSONLinesMessageCodec) EncodeJSONMap(message map[string]interface{}) ([]byte, error) {
+	data, err := c.JSONToStruct(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+	return c.Encode(message["MsgType"], data)
+}
+
+// JSONToStruct implements MessageCodec.
+// Values written as JSON objects or arrays in a string are decoded, so CSV cells can hold nested data.
+func (c *JSONLinesMessageCodec) JSONToStruct(jsonMap map[string]interface{}) (codec.BinaryCodec, error) {
+	message := make(JSONMessage, len(jsonMap))
+	for k, v := range jsonMap {
+		if k == "StepId" || (k == "MsgType" && v == "") {
+			continue
+		}
+		if s, ok := v.(string); ok && (strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")) {
+			v = decodeJSONString(s)
+		}
+		message[k] = v
+	}
+	return &message, nil
+}
+
+// Encode implements MessageCodec, the message is written as one line.
+func (c *JSONLinesMessageCodec) Encode(_ interface{}, message codec.BinaryCodec) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := message.Encode(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode implements MessageCodec, it returns the MsgType field if any.
+func (c *JSONLinesMessageCodec) Decode(data []byte) (interface{}, codec.BinaryCodec, error) {
+	var message JSONMessage
+	if err := message.Decode(bytes.NewBuffer(data)); err != nil {
+		return nil, nil, err
+	}
+	return message["MsgType"], &message, nil
+}
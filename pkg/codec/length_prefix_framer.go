@@ -0,0 +1,97 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// maxLengthPrefixedBody guards against corrupt or hostile length prefixes
+const maxLengthPrefixedBody = 16 << 20
+
+// LengthPrefixFramer is a framer for protocols whose frames start with a
+// big-endian length prefix counting the body only.
+type LengthPrefixFramer struct {
+	Proto string
+	// PrefixLen is the size of the length prefix in bytes: 1, 2, 4 or 8.
+	PrefixLen int
+}
+
+// NewLengthPrefixFramer creates a LengthPrefixFramer, a zero prefixLen defaults to 4.
+func NewLengthPrefixFramer(proto string, prefixLen int) (*LengthPrefixFramer, error) {
+	if prefixLen == 0 {
+		prefixLen = 4
+	}
+	switch prefixLen {
+	case 1, 2, 4, 8:
+		return &LengthPrefixFramer{Proto: proto, PrefixLen: prefixLen}, nil
+	default:
+		return nil, fmt.Errorf("unsupported length prefix size: %d", prefixLen)
+	}
+}
+
+// ProtoName implements Framer.
+func (f *LengthPrefixFramer) ProtoName() string {
+	return f.Proto
+}
+
+// ReadFrame implements Framer.
+func (f *LengthPrefixFramer) ReadFrame(conn net.Conn) ([]byte, error) {
+	head := make([]byte, f.PrefixLen)
+	_, err := io.ReadFull(conn, head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive message: %w", err)
+	}
+	size := f.bodyLen(head)
+	if size > maxLengthPrefixedBody {
+		return nil, fmt.Errorf("frame body of %d bytes exceeds %d bytes: %w", size, maxLengthPrefixedBody, ErrInvalidPacket)
+	}
+	body := make([]byte, size)
+	_, err = io.ReadFull(conn, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive message: %w", err)
+	}
+	return append(head, body...), nil
+}
+
+// Frame prepends the length prefix to body.
+func (f *LengthPrefixFramer) Frame(body []byte) ([]byte, error) {
+	size := uint64(len(body))
+	if f.PrefixLen < 8 && size >= 1<<(8*f.PrefixLen) {
+		return nil, fmt.Errorf("body of %d bytes exceeds %d-byte length prefix", size, f.PrefixLen)
+	}
+	frame := make([]byte, f.PrefixLen, f.PrefixLen+len(body))
+	switch f.PrefixLen {
+	case 1:
+		frame[0] = byte(size)
+	case 2:
+		binary.BigEndian.PutUint16(frame, uint16(size))
+	case 4:
+		binary.BigEndian.PutUint32(frame, uint32(size))
+	default:
+		binary.BigEndian.PutUint64(frame, size)
+	}
+	return append(frame, body...), nil
+}
+
+// Body strips the length prefix from a complete frame.
+func (f *LengthPrefixFramer) Body(frame []byte) ([]byte, error) {
+	if len(frame) < f.PrefixLen || uint64(len(frame)-f.PrefixLen) != f.bodyLen(frame[:f.PrefixLen]) {
+		return nil, ErrInvalidPacket
+	}
+	return frame[f.PrefixLen:], nil
+}
+
+func (f *LengthPrefixFramer) bodyLen(head []byte) uint64 {
+	switch f.PrefixLen {
+	case 1:
+		return uint64(head[0])
+	case 2:
+		return uint64(binary.BigEndian.Uint16(head))
+	case 4:
+		return uint64(binary.BigEndian.Uint32(head))
+	default:
+		return binary.BigEndian.Uint64(head)
+	}
+}
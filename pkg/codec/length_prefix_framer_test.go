@@ -0,0 +1,32 @@
+package codec
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLengthPrefixFramer_ReadFrame(t *testing.T) {
+	framer, err := NewLengthPrefixFramer("protobuf", 4)
+	require.NoError(t, err)
+	good, err := framer.Frame([]byte("hello"))
+	require.NoError(t, err)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go func() {
+		client.Write(good)
+		client.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	}()
+
+	frame, err := framer.ReadFrame(server)
+	assert.NoError(t, err)
+	assert.Equal(t, good, frame)
+
+	_, err = framer.ReadFrame(server)
+	assert.True(t, errors.Is(err, ErrInvalidPacket), "a length over the maximum is not allocated")
+}
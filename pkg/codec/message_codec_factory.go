@@ -23,6 +23,16 @@ const (
 	StepSZSE = "step-szse"
 	// StepSSE shanghai stock exchange step protocol
 	StepSSE = "step-sse"
+	// IMIX interbank market tag=value protocol
+	IMIX = "imix"
+	// JSONLines newline-delimited JSON objects
+	JSONLines = "json-lines"
+	// Protobuf length-prefixed protobuf messages, descriptors are loaded at runtime
+	// so the codec is created by NewProtobufMessageCodec instead of the factory
+	Protobuf = "protobuf"
+	// HTTP JSON requests and responses of the http communication,
+	// the codec is created by the http simulators whatever the configured protocol
+	HTTP = "http"
 )
 
 var (
@@ -56,6 +66,8 @@ type DefaultMessageCodecFactory struct {
 // - "binary-sse"
 // - "step-szse"
 // - "step-sse"
+// - "imix"
+// - "json-lines"
 func (f *DefaultMessageCodecFactory) GetCodec(proto string) (MessageCodec, error) {
 	switch proto {
 	case string(BinaryRisk):
@@ -64,6 +76,10 @@ func (f *DefaultMessageCodecFactory) GetCodec(proto string) (MessageCodec, error
 		return &BinarySzseMessageCodec{}, nil
 	case string(BinarySSE):
 		return &BinarySseMessageCodec{}, nil
+	case string(IMIX):
+		return &ImixMessageCodec{}, nil
+	case string(JSONLines):
+		return &JSONLinesMessageCodec{}, nil
 	default:
 		ErrUnsupportedProtocol := errors.New("unsupported protocol")
 		return nil, ErrUnsupportedProtocol
@@ -79,6 +95,10 @@ func (f *DefaultMessageCodecFactory) GetFramer(proto string) (Framer, error) {
 		return &SzseBinFramer{}, nil
 	case string(BinarySSE):
 		return &SseBinFramer{}, nil
+	case string(IMIX):
+		return &ImixFramer{}, nil
+	case string(JSONLines):
+		return &JSONLinesFramer{}, nil
 	default:
 		ErrUnsupportedProtocol := errors.New("unsupported protocol")
 		return nil, ErrUnsupportedProtocol
@@ -0,0 +1,79 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// MessageToMap converts a decoded message to a JSON-like map, the inverse of MessageCodec.JSONToStruct.
+// Binary messages are converted by their exported fields, numbers are kept as json.Number.
+func MessageToMap(message codec.BinaryCodec) (map[string]interface{}, error) {
+	var data []byte
+	var err error
+	switch m := message.(type) {
+	case *JSONMessage:
+		fields := make(map[string]interface{}, len(*m))
+		for k, v := range *m {
+			fields[k] = v
+		}
+		return fields, nil
+	case *ImixMessage:
+		fields := make(map[string]interface{}, len(m.Fields)+1)
+		for k, v := range m.Fields {
+			fields[k] = v
+		}
+		fields["MsgType"] = m.MsgType
+		return fields, nil
+	case *ProtoMessage:
+		data, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(m.Message)
+	default:
+		data, err = json.Marshal(message)
+	}
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	fields := make(map[string]interface{})
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// UnknownFields returns the keys of data, as given to MessageCodec.JSONToStruct, that are not a field of the
+// message it built, at any depth, StepId and MsgType aside. Such keys are silently ignored by ConvertMapToStruct.
+// Only binary messages have a fixed set of fields, nil is returned for the others.
+func UnknownFields(data map[string]interface{}, message codec.BinaryCodec) ([]string, error) {
+	switch message.(type) {
+	case *JSONMessage, *ImixMessage, *ProtoMessage:
+		return nil, nil
+	}
+	fields, err := MessageToMap(message)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	collectFieldNames(fields, known)
+	var unknown []string
+	for k := range data {
+		if k != "StepId" && k != "MsgType" && !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+func collectFieldNames(fields map[string]interface{}, names map[string]bool) {
+	for k, v := range fields {
+		names[k] = true
+		if nested, ok := v.(map[string]interface{}); ok {
+			collectFieldNames(nested, names)
+		}
+	}
+}
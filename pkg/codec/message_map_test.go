@@ -0,0 +1,52 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOrder struct {
+	ClOrdID  string
+	OrderQty uint64
+}
+
+func (o *testOrder) Encode(*bytes.Buffer) error { return nil }
+func (o *testOrder) Decode(*bytes.Buffer) error { return nil }
+
+func TestMessageToMap(t *testing.T) {
+	imix := &ImixMessage{MsgType: "AE", Fields: map[string]interface{}{"TradeID": "T1"}}
+	fields, err := MessageToMap(imix)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"MsgType": "AE", "TradeID": "T1"}, fields)
+
+	fields, err = MessageToMap(&JSONMessage{"MsgType": "Ack"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"MsgType": "Ack"}, fields)
+
+	fields, err = MessageToMap(&testOrder{ClOrdID: "c1", OrderQty: 100})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ClOrdID": "c1", "OrderQty": json.Number("100")}, fields)
+}
+
+type testExtended struct {
+	ClOrdID string
+	Extend  *testOrder `json:"ApplExtend"`
+}
+
+func (o *testExtended) Encode(*bytes.Buffer) error { return nil }
+func (o *testExtended) Decode(*bytes.Buffer) error { return nil }
+
+func TestUnknownFields(t *testing.T) {
+	data := map[string]interface{}{"StepId": "s1", "MsgType": "1", "ClOrdID": "c1", "OrderQty": "100", "Price": "10"}
+	unknown, err := UnknownFields(data, &testExtended{Extend: &testOrder{}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Price"}, unknown, "nested fields are known")
+
+	unknown, err = UnknownFields(data, &JSONMessage{})
+	require.NoError(t, err)
+	assert.Empty(t, unknown)
+}
@@ -0,0 +1,460 @@
+package codec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ProtobufOptions configures a ProtobufMessageCodec.
+type ProtobufOptions struct {
+	// ProtoFiles are .proto source files compiled when the codec is created.
+	ProtoFiles []string
+	// ImportPaths are the directories used to resolve ProtoFiles and their imports.
+	ImportPaths []string
+	// DescriptorSet is a serialized FileDescriptorSet, e.g. from protoc --descriptor_set_out.
+	DescriptorSet string
+	// Message is the full name of the envelope message carried by every frame.
+	// When empty every frame carries a google.protobuf.Any.
+	Message string
+	// LengthPrefix is the size in bytes of the frame length prefix, default 4.
+	LengthPrefix int
+}
+
+// ProtoMessage adapts a protobuf message to codec.BinaryCodec,
+// so it can be passed through simulators like the generated binary messages.
+type ProtoMessage struct {
+	proto.Message
+}
+
+// Encode implements codec.BinaryCodec.
+func (m *ProtoMessage) Encode(buf *bytes.Buffer) error {
+	data, err := proto.Marshal(m.Message)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// Decode implements codec.BinaryCodec.
+func (m *ProtoMessage) Decode(buf *bytes.Buffer) error {
+	err := proto.Unmarshal(buf.Bytes(), m.Message)
+	buf.Reset()
+	return err
+}
+
+// String returns the message in protobuf text format.
+func (m *ProtoMessage) String() string {
+	return prototext.Format(m.Message)
+}
+
+// ProtobufMessageCodec is a codec for protobuf messages described by descriptors loaded at runtime.
+type ProtobufMessageCodec struct {
+	files    *protoregistry.Files
+	types    *dynamicpb.Types
+	envelope protoreflect.MessageDescriptor
+	framer   *LengthPrefixFramer
+}
+
+// NewProtobufMessageCodec loads the descriptors described by opts and creates a ProtobufMessageCodec.
+func NewProtobufMessageCodec(opts ProtobufOptions) (*ProtobufMessageCodec, error) {
+	framer, err := NewLengthPrefixFramer(Protobuf, opts.LengthPrefix)
+	if err != nil {
+		return nil, err
+	}
+	files, err := loadProtoFiles(opts)
+	if err != nil {
+		return nil, err
+	}
+	c := &ProtobufMessageCodec{
+		files:  files,
+		types:  dynamicpb.NewTypes(files),
+		framer: framer,
+	}
+	if opts.Message != "" {
+		c.envelope, err = c.messageDescriptor(opts.Message)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func loadProtoFiles(opts ProtobufOptions) (*protoregistry.Files, error) {
+	files := new(protoregistry.Files)
+	if opts.DescriptorSet != "" {
+		data, err := os.ReadFile(opts.DescriptorSet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+		}
+		var set descriptorpb.FileDescriptorSet
+		if err := proto.Unmarshal(data, &set); err != nil {
+			return nil, fmt.Errorf("failed to decode descriptor set: %w", err)
+		}
+		files, err = protodesc.NewFiles(&set)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load descriptor set: %w", err)
+		}
+	}
+	if len(opts.ProtoFiles) > 0 {
+		compiler := protocompile.Compiler{
+			Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+				ImportPaths: opts.ImportPaths,
+			}),
+		}
+		compiled, err := compiler.Compile(context.Background(), opts.ProtoFiles...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile proto files: %w", err)
+		}
+		for _, fd := range compiled {
+			if err := registerProtoFile(files, fd); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// registerProtoFile registers fd after its imports, skipping files already known.
+func registerProtoFile(files *protoregistry.Files, fd protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(fd.Path()); err == nil {
+		return nil
+	}
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := registerProtoFile(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	if err := files.RegisterFile(fd); err != nil {
+		return fmt.Errorf("failed to register %s: %w", fd.Path(), err)
+	}
+	return nil
+}
+
+func (c *ProtobufMessageCodec) messageDescriptor(name string) (protoreflect.MessageDescriptor, error) {
+	desc, err := c.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown message: %s", name)
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", name)
+	}
+	return md, nil
+}
+
+// Framer returns the framer matching the codec's length prefix.
+func (c *ProtobufMessageCodec) Framer() Framer {
+	return c.framer
+}
+
+// ProtoName implements MessageCodec.
+func (c *ProtobufMessageCodec) ProtoName() string {
+	return Protobuf
+}
+
+// EncodeJSONMap implements MessageCodec.
+func (c *ProtobufMessageCodec) EncodeJSONMap(message map[string]interface{}) ([]byte, error) {
+	data, err := c.JSONToStruct(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+	return c.Encode(message["MsgType"], data)
+}
+
+// JSONToStruct implements MessageCodec.
+// MsgType holds the full message name, it may be omitted when an envelope message is configured.
+func (c *ProtobufMessageCodec) JSONToStruct(jsonMap map[string]interface{}) (codec.BinaryCodec, error) {
+	name, _ := jsonMap["MsgType"].(string)
+	var desc protoreflect.MessageDescriptor
+	if name == "" && c.envelope != nil {
+		desc = c.envelope
+	} else {
+		var err error
+		desc, err = c.messageDescriptor(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	message := dynamicpb.NewMessage(desc)
+	if err := ConvertMapToProto(jsonMap, message); err != nil {
+		return nil, err
+	}
+	return &ProtoMessage{message}, nil
+}
+
+// Encode implements MessageCodec.
+// The message is wrapped in a google.protobuf.Any unless an envelope message is configured.
+func (c *ProtobufMessageCodec) Encode(_ interface{}, message codec.BinaryCodec) ([]byte, error) {
+	msg, ok := message.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("not a protobuf message: %T", message)
+	}
+	if c.envelope != nil {
+		if name := msg.ProtoReflect().Descriptor().FullName(); name != c.envelope.FullName() {
+			return nil, fmt.Errorf("message %s is not the envelope %s", name, c.envelope.FullName())
+		}
+	} else {
+		wrapped, err := anypb.New(msg)
+		if err != nil {
+			return nil, err
+		}
+		msg = wrapped
+	}
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return c.framer.Frame(body)
+}
+
+// Decode implements MessageCodec.
+// It returns the full message name and the decoded message.
+func (c *ProtobufMessageCodec) Decode(data []byte) (interface{}, codec.BinaryCodec, error) {
+	body, err := c.framer.Body(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := proto.UnmarshalOptions{Resolver: c.types}
+	var msg proto.Message
+	if c.envelope != nil {
+		msg = dynamicpb.NewMessage(c.envelope)
+		if err := opts.Unmarshal(body, msg); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		var wrapped anypb.Any
+		if err := opts.Unmarshal(body, &wrapped); err != nil {
+			return nil, nil, err
+		}
+		msg, err = anypb.UnmarshalNew(&wrapped, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return string(msg.ProtoReflect().Descriptor().FullName()), &ProtoMessage{msg}, nil
+}
+
+// ConvertMapToProto converts a JSON-like map to a protobuf message field by field.
+// Keys match either the proto field name or its JSON name, other keys are ignored.
+// Nested messages, lists and maps accept either decoded JSON values or JSON strings.
+func ConvertMapToProto(data map[string]interface{}, msg proto.Message) error {
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		raw, ok := data[string(fd.Name())]
+		if !ok {
+			raw, ok = data[fd.JSONName()]
+		}
+		if !ok {
+			continue
+		}
+		if err := setProtoField(m, fd, raw); err != nil {
+			return fmt.Errorf("field '%s': %w", fd.Name(), err)
+		}
+	}
+	return nil
+}
+
+func setProtoField(m protoreflect.Message, fd protoreflect.FieldDescriptor, raw interface{}) error {
+	switch {
+	case fd.IsMap():
+		entries, ok := decodeJSONString(raw).(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot convert %T to map", raw)
+		}
+		mp := m.Mutable(fd).Map()
+		for k, v := range entries {
+			key, err := protoScalar(fd.MapKey(), k)
+			if err != nil {
+				return err
+			}
+			if fd.MapValue().Message() != nil {
+				if err := setProtoMessage(mp.Mutable(key.MapKey()).Message(), v); err != nil {
+					return err
+				}
+				continue
+			}
+			val, err := protoScalar(fd.MapValue(), v)
+			if err != nil {
+				return err
+			}
+			mp.Set(key.MapKey(), val)
+		}
+	case fd.IsList():
+		items, ok := decodeJSONString(raw).([]interface{})
+		if !ok {
+			items = []interface{}{raw}
+		}
+		list := m.Mutable(fd).List()
+		for _, item := range items {
+			if fd.Message() != nil {
+				elem := list.NewElement()
+				if err := setProtoMessage(elem.Message(), item); err != nil {
+					return err
+				}
+				list.Append(elem)
+				continue
+			}
+			val, err := protoScalar(fd, item)
+			if err != nil {
+				return err
+			}
+			list.Append(val)
+		}
+	case fd.Message() != nil:
+		return setProtoMessage(m.Mutable(fd).Message(), raw)
+	default:
+		val, err := protoScalar(fd, raw)
+		if err != nil {
+			return err
+		}
+		m.Set(fd, val)
+	}
+	return nil
+}
+
+func setProtoMessage(m protoreflect.Message, raw interface{}) error {
+	fields, ok := decodeJSONString(raw).(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("cannot convert %T to message", raw)
+	}
+	return ConvertMapToProto(fields, m.Interface())
+}
+
+// decodeJSONString decodes raw when it is a JSON object or array written as a string,
+// its numbers are kept as json.Number so that 64-bit integers keep their precision
+func decodeJSONString(raw interface{}) interface{} {
+	s, ok := raw.(string)
+	if !ok {
+		return raw
+	}
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return raw
+	}
+	return v
+}
+
+func protoScalar(fd protoreflect.FieldDescriptor, raw interface{}) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		switch v := raw.(type) {
+		case bool:
+			return protoreflect.ValueOfBool(v), nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			return protoreflect.ValueOfBool(b), err
+		}
+	case protoreflect.StringKind:
+		switch v := raw.(type) {
+		case string:
+			return protoreflect.ValueOfString(v), nil
+		case float64:
+			return protoreflect.ValueOfString(strconv.FormatFloat(v, 'f', -1, 64)), nil
+		case int:
+			return protoreflect.ValueOfString(strconv.Itoa(v)), nil
+		case json.Number:
+			return protoreflect.ValueOfString(v.String()), nil
+		}
+	case protoreflect.BytesKind:
+		if v, ok := raw.(string); ok {
+			return protoreflect.ValueOfBytes([]byte(v)), nil
+		}
+	case protoreflect.EnumKind:
+		if v, ok := raw.(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(v)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+		}
+		n, err := toInt64(raw, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := toInt64(raw, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := toInt64(raw, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := toUint64(raw, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := toUint64(raw, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := toFloat64(raw, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := toFloat64(raw, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	}
+	return protoreflect.Value{}, fmt.Errorf("cannot convert %T to %s", raw, fd.Kind())
+}
+
+func toInt64(raw interface{}, bitSize int) (int64, error) {
+	switch v := raw.(type) {
+	case float64:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case json.Number:
+		return strconv.ParseInt(v.String(), 10, bitSize)
+	case string:
+		return strconv.ParseInt(v, 10, bitSize)
+	}
+	return 0, fmt.Errorf("cannot convert %T to int", raw)
+}
+
+func toUint64(raw interface{}, bitSize int) (uint64, error) {
+	switch v := raw.(type) {
+	case float64:
+		if v < 0 {
+			return 0, fmt.Errorf("cannot convert negative %v to uint", v)
+		}
+		return uint64(v), nil
+	case int:
+		if v < 0 {
+			return 0, fmt.Errorf("cannot convert negative %d to uint", v)
+		}
+		return uint64(v), nil
+	case json.Number:
+		return strconv.ParseUint(v.String(), 10, bitSize)
+	case string:
+		return strconv.ParseUint(v, 10, bitSize)
+	}
+	return 0, fmt.Errorf("cannot convert %T to uint", raw)
+}
+
+func toFloat64(raw interface{}, bitSize int) (float64, error) {
+	switch v := raw.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case json.Number:
+		return strconv.ParseFloat(v.String(), bitSize)
+	case string:
+		return strconv.ParseFloat(v, bitSize)
+	}
+	return 0, fmt.Errorf("cannot convert %T to float", raw)
+}
@@ -0,0 +1,89 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestProtobufMessageCodec_EncodeDecodeAny(t *testing.T) {
+	c, err := NewProtobufMessageCodec(ProtobufOptions{
+		ProtoFiles:  []string{"order.proto"},
+		ImportPaths: []string{"testdata"},
+	})
+	require.NoError(t, err)
+
+	message := map[string]interface{}{
+		"StepId":      "new_order_001",
+		"MsgType":     "order.NewOrder",
+		"cl_ord_id":   "c0001",
+		"securityId":  "000001",
+		"side":        "SELL",
+		"price":       "100",
+		"order_qty":   "1000",
+		"tags":        `["a","b"]`,
+		"extra":       `{"k":"v"}`,
+		"not_a_field": "ignored",
+	}
+	encoded, err := c.EncodeJSONMap(message)
+	require.NoError(t, err)
+
+	msgType, decoded, err := c.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "order.NewOrder", msgType)
+
+	expect, err := c.JSONToStruct(message)
+	require.NoError(t, err)
+	result := validate.CompareStruct(expect, decoded)
+	assert.True(t, result.Equal, result.Diffs)
+
+	message["price"] = "101"
+	expect, err = c.JSONToStruct(message)
+	require.NoError(t, err)
+	result = validate.CompareStruct(expect, decoded)
+	assert.Equal(t, []validate.Diff{{Path: "price", Expect: int64(101), Actual: int64(100)}}, result.Diffs)
+}
+
+func TestProtobufMessageCodec_EncodeDecodeEnvelope(t *testing.T) {
+	c, err := NewProtobufMessageCodec(ProtobufOptions{
+		ProtoFiles:   []string{"testdata/order.proto"},
+		Message:      "order.Envelope",
+		LengthPrefix: 2,
+	})
+	require.NoError(t, err)
+
+	encoded, err := c.EncodeJSONMap(map[string]interface{}{
+		"new_order": map[string]interface{}{"cl_ord_id": "c0001", "side": float64(1)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, len(encoded)-2, int(encoded[0])<<8|int(encoded[1]))
+
+	msgType, decoded, err := c.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "order.Envelope", msgType)
+	assert.Contains(t, decoded.(*ProtoMessage).String(), "c0001")
+
+	_, err = c.EncodeJSONMap(map[string]interface{}{"MsgType": "order.NewOrder"})
+	assert.Error(t, err)
+}
+
+func TestProtobufMessageCodec_LargeIntegers(t *testing.T) {
+	c, err := NewProtobufMessageCodec(ProtobufOptions{ProtoFiles: []string{"testdata/order.proto"}})
+	require.NoError(t, err)
+	md, err := c.messageDescriptor("order.NewOrder")
+	require.NoError(t, err)
+
+	msg := dynamicpb.NewMessage(md)
+	require.NoError(t, setProtoMessage(msg, `{"price": 9007199254740993, "seq_num": 18446744073709551615}`))
+	assert.Equal(t, int64(9007199254740993), msg.Get(md.Fields().ByName("price")).Int())
+	assert.Equal(t, uint64(18446744073709551615), msg.Get(md.Fields().ByName("seq_num")).Uint())
+
+	assert.Error(t, setProtoMessage(dynamicpb.NewMessage(md), `{"seq_num": -1}`))
+	_, err = toUint64(float64(-1), 64)
+	assert.Error(t, err)
+	_, err = toUint64(-1, 32)
+	assert.Error(t, err)
+}
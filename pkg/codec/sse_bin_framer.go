@@ -7,7 +7,7 @@ import (
 	"net"
 )
 
-// SseBinFramer is a framer for the risk binary protocol.
+// SseBinFramer is a framer for the sse binary protocol.
 type SseBinFramer struct{}
 
 // ProtoName implements Framer.
@@ -16,6 +16,7 @@ func (r *SseBinFramer) ProtoName() string {
 }
 
 // ReadFrame implements Framer.
+// If the checksum trailer is wrong the frame is returned together with a *ChecksumError.
 func (r *SseBinFramer) ReadFrame(conn net.Conn) ([]byte, error) {
 	head := make([]byte, 16)
 	_, err := io.ReadFull(conn, head)
@@ -25,9 +26,20 @@ func (r *SseBinFramer) ReadFrame(conn net.Conn) ([]byte, error) {
 	//bodylen + checksum
 	bodyLen := binary.BigEndian.Uint32(head[12:16]) + 4
 	body := make([]byte, bodyLen)
-	_, er := io.ReadFull(conn, body)
-	if er != nil {
+	_, err = io.ReadFull(conn, body)
+	if err != nil {
 		return nil, fmt.Errorf("failed to receive message: %w", err)
 	}
-	return append(head, body...), nil
+	frame := append(head, body...)
+	return frame, r.VerifyChecksum(frame)
+}
+
+// VerifyChecksum implements ChecksumFramer.
+func (r *SseBinFramer) VerifyChecksum(frame []byte) error {
+	return verifyTrailerChecksum(frame)
+}
+
+// CorruptChecksum implements ChecksumFramer.
+func (r *SseBinFramer) CorruptChecksum(frame []byte) {
+	corruptTrailerChecksum(frame)
 }
@@ -7,7 +7,7 @@ import (
 	"net"
 )
 
-// SzseBinFramer is a framer for the risk binary protocol.
+// SzseBinFramer is a framer for the szse binary protocol.
 type SzseBinFramer struct{}
 
 // ProtoName implements Framer.
@@ -16,6 +16,7 @@ func (r *SzseBinFramer) ProtoName() string {
 }
 
 // ReadFrame implements Framer.
+// If the checksum trailer is wrong the frame is returned together with a *ChecksumError.
 func (r *SzseBinFramer) ReadFrame(conn net.Conn) ([]byte, error) {
 	head := make([]byte, 8)
 	_, err := io.ReadFull(conn, head)
@@ -25,9 +26,20 @@ func (r *SzseBinFramer) ReadFrame(conn net.Conn) ([]byte, error) {
 	//bodylen + 4
 	bodyLen := binary.BigEndian.Uint32(head[4:8]) + 4
 	body := make([]byte, bodyLen)
-	_, er := io.ReadFull(conn, body)
-	if er != nil {
+	_, err = io.ReadFull(conn, body)
+	if err != nil {
 		return nil, fmt.Errorf("failed to receive message: %w", err)
 	}
-	return append(head, body...), nil
+	frame := append(head, body...)
+	return frame, r.VerifyChecksum(frame)
+}
+
+// VerifyChecksum implements ChecksumFramer.
+func (r *SzseBinFramer) VerifyChecksum(frame []byte) error {
+	return verifyTrailerChecksum(frame)
+}
+
+// CorruptChecksum implements ChecksumFramer.
+func (r *SzseBinFramer) CorruptChecksum(frame []byte) {
+	corruptTrailerChecksum(frame)
 }
@@ -1,7 +1,14 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	log "github.com/sirupsen/logrus"
@@ -12,6 +19,121 @@ type GwAutoConfig struct {
 	// Simulators is a list of simulator configurations
 	Simulators   []SimulatorConfig `toml:"simulators"`
 	SimulatorMap map[string]SimulatorConfig
+	// Differential runs the cases against two gateways, see DifferentialConfig
+	Differential DifferentialConfig `toml:"differential"`
+	// Parallel gives each worker running cases in parallel its own simulators, see ParallelConfig
+	Parallel ParallelConfig `toml:"parallel"`
+	// Latency sets how messages are correlated when measuring latencies, see LatencyConfig
+	Latency LatencyConfig `toml:"latency"`
+}
+
+// LatencyConfig represents how a received message is matched with the message sent for the same order
+// correlation_field, the field carrying the same value in both, ClOrdID when empty
+type LatencyConfig struct {
+	CorrelationField string `toml:"correlation_field"`
+}
+
+// ParallelConfig represents the simulators of the workers running cases in parallel
+// port_step, worker i uses the configured addresses with their port plus i*port_step, default 100,
+// so the gateway under test needs an instance, or a session, per worker on those ports
+type ParallelConfig struct {
+	PortStep int `toml:"port_step"`
+}
+
+// defaultPortStep separates the ports of parallel workers when port_step is not set
+const defaultPortStep = 100
+
+// ForWorker returns a copy of the configuration for parallel worker i, worker 0 using it unchanged.
+// The ports of the addresses are shifted by i*port_step and capture files get a -i suffix.
+func (c *GwAutoConfig) ForWorker(i int) (GwAutoConfig, error) {
+	step := c.Parallel.PortStep
+	if step == 0 {
+		step = defaultPortStep
+	}
+	result := GwAutoConfig{Simulators: make([]SimulatorConfig, len(c.Simulators)), Latency: c.Latency}
+	for j, s := range c.Simulators {
+		var err error
+		if s.ServerAddress, err = shiftPort(s.ServerAddress, i*step); err != nil {
+			return result, fmt.Errorf("simulator %s: %w", s.Name, err)
+		}
+		if s.ListenAddress, err = shiftPort(s.ListenAddress, i*step); err != nil {
+			return result, fmt.Errorf("simulator %s: %w", s.Name, err)
+		}
+		if s.CaptureFile != "" && i > 0 {
+			ext := filepath.Ext(s.CaptureFile)
+			s.CaptureFile = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(s.CaptureFile, ext), i, ext)
+		}
+		result.Simulators[j] = s
+	}
+	result.InitConfigMap()
+	return result, nil
+}
+
+// shiftPort adds delta to the port of a host:port address, or of a URL
+// Port 0 asks the system for a free port and is left as is.
+func shiftPort(address string, delta int) (string, error) {
+	if address == "" || delta == 0 {
+		return address, nil
+	}
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return "", err
+		}
+		if u.Host, err = shiftPort(u.Host, delta); err != nil {
+			return "", err
+		}
+		return u.String(), nil
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return "", fmt.Errorf("invalid port %q", port)
+	}
+	if n == 0 {
+		return address, nil
+	}
+	return net.JoinHostPort(host, strconv.Itoa(n+delta)), nil
+}
+
+// DifferentialConfig represents two gateways running the same cases side by side
+// targets, the two gateways, baseline first
+// compare, simulators whose received messages are compared between the targets instead of
+// with the test data, every tgw simulator when empty
+// ignore, fields not compared
+type DifferentialConfig struct {
+	Targets []TargetConfig `toml:"targets"`
+	Compare []string       `toml:"compare"`
+	Ignore  []string       `toml:"ignore"`
+}
+
+// TargetConfig represents one gateway of a differential run
+// name, shown in the report
+// addresses, address of each simulator for this gateway by simulator name,
+// the server_address of oms simulators and the listen_address of the others
+type TargetConfig struct {
+	Name      string            `toml:"name"`
+	Addresses map[string]string `toml:"addresses"`
+}
+
+// ForTarget returns a copy of the configuration whose simulators use the addresses of target
+func (c *GwAutoConfig) ForTarget(target TargetConfig) GwAutoConfig {
+	result := GwAutoConfig{Simulators: make([]SimulatorConfig, len(c.Simulators)), Latency: c.Latency}
+	for i, s := range c.Simulators {
+		if address, ok := target.Addresses[s.Name]; ok {
+			if s.Type == "oms" {
+				s.ServerAddress = address
+			} else {
+				s.ListenAddress = address
+			}
+		}
+		result.Simulators[i] = s
+	}
+	result.InitConfigMap()
+	return result
 }
 
 // InitConfigMap convert slice to map
@@ -27,20 +149,112 @@ func (c *GwAutoConfig) InitConfigMap() {
 // SimulatorConfig represents the configuration for a simulator
 // It includes the
 // name, shuld be unique
-// type, type can be oms or tgw
-// communication: common types are tcp, udp, http
-// protocol,  protocol can be binary-szse, json-szse, etc.
-// server_address, the address of the server to connect to
-// listen_address, the address to listen on for incoming connections
+// type, type can be oms, tgw or proxy, a proxy forwards listen_address to server_address over tcp
+// communication: tcp (default), ws, udp or http, a WebSocket message or a datagram carries one encoded message,
+// http exchanges JSON requests and responses, oms being the client and tgw the server
+// protocol,  protocol can be binary-szse, imix, json-lines, protobuf, etc. not used for http
+// server_address, the address of the server to connect to, ws://host:port/path for ws, http://host:port for http
+// listen_address, the address to listen on for incoming connections, a multicast group joins it for udp
+// interface, network interface used to join a multicast group, the default interface when empty
 // auto_start, whether to start the simulator automatically
+// corrupt_checksum, tgw only, send frames with a deliberately wrong checksum trailer
+// protobuf, settings of the protobuf protocol
+// reconnect, oms over tcp only, retry and backoff policy of the connection
+// faults, tcp only, faults injected when sending frames, see FaultConfig
+// capture_file, proxy only, JSON lines file recording every forwarded message
 type SimulatorConfig struct {
-	Name          string `toml:"name"`
-	Type          string `toml:"type"`
-	Communication string `toml:"communication"`
-	Protocol      string `toml:"protocol"`
-	ServerAddress string `toml:"server_address"`
-	ListenAddress string `toml:"listen_address"`
-	AutoStart     bool   `toml:"auto_start"`
+	Name            string          `toml:"name"`
+	Type            string          `toml:"type"`
+	Communication   string          `toml:"communication"`
+	Protocol        string          `toml:"protocol"`
+	ServerAddress   string          `toml:"server_address"`
+	ListenAddress   string          `toml:"listen_address"`
+	Interface       string          `toml:"interface"`
+	AutoStart       bool            `toml:"auto_start"`
+	CorruptChecksum bool            `toml:"corrupt_checksum"`
+	Protobuf        ProtobufConfig  `toml:"protobuf"`
+	Reconnect       ReconnectConfig `toml:"reconnect"`
+	Faults          FaultConfig     `toml:"faults"`
+	CaptureFile     string          `toml:"capture_file"`
+}
+
+// ReconnectConfig represents the connection policy of a client simulator
+// retries, connection attempts after the first failed one, -1 retries until closed
+// backoff_ms, delay before the first retry, doubled after every failed retry, default 500
+// max_backoff_ms, upper bound of the delay, default 5000
+// auto_reconnect, connect again with the same policy when the server closes the connection
+type ReconnectConfig struct {
+	Retries       int  `toml:"retries"`
+	BackoffMs     int  `toml:"backoff_ms"`
+	MaxBackoffMs  int  `toml:"max_backoff_ms"`
+	AutoReconnect bool `toml:"auto_reconnect"`
+}
+
+// ProtobufConfig represents the settings of the protobuf protocol
+// proto_files, .proto source files compiled at start up
+// import_paths, directories used to resolve proto_files and their imports
+// descriptor_set, a FileDescriptorSet file, e.g. from protoc --descriptor_set_out
+// message, full name of the envelope message of every frame, google.protobuf.Any when empty
+// length_prefix, size in bytes of the big-endian frame length prefix: 1, 2, 4 or 8, default 4
+type ProtobufConfig struct {
+	ProtoFiles    []string `toml:"proto_files"`
+	ImportPaths   []string `toml:"import_paths"`
+	DescriptorSet string   `toml:"descriptor_set"`
+	Message       string   `toml:"message"`
+	LengthPrefix  int      `toml:"length_prefix"`
+}
+
+// FaultConfig represents the network faults injected by a simulator when sending frames
+// latency_ms, delay added before every write, plus up to jitter_ms at random
+// bandwidth_bps, bytes per second, writes are throttled in small chunks
+// split_bytes, every frame is written in chunks of at most split_bytes, split_gap_ms apart
+// coalesce, frames are buffered and written together by groups of coalesce,
+// a partial group is written after coalesce_wait_ms, default 100
+// reset_rate, probability of resetting the connection instead of writing a frame
+// corrupt_rate, probability of flipping each byte written
+// seed, seed of the random faults, the current time when 0
+type FaultConfig struct {
+	LatencyMs      int     `toml:"latency_ms"`
+	JitterMs       int     `toml:"jitter_ms"`
+	BandwidthBps   int     `toml:"bandwidth_bps"`
+	SplitBytes     int     `toml:"split_bytes"`
+	SplitGapMs     int     `toml:"split_gap_ms"`
+	Coalesce       int     `toml:"coalesce"`
+	CoalesceWaitMs int     `toml:"coalesce_wait_ms"`
+	ResetRate      float64 `toml:"reset_rate"`
+	CorruptRate    float64 `toml:"corrupt_rate"`
+	Seed           int64   `toml:"seed"`
+}
+
+// ParseFaultConfig reads a FaultConfig from a JSON-like map keyed by the toml names,
+// such as the test data of a step. Other keys are ignored.
+func ParseFaultConfig(data map[string]interface{}) (FaultConfig, error) {
+	var faults FaultConfig
+	v := reflect.ValueOf(&faults).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key := v.Type().Field(i).Tag.Get("toml")
+		raw, ok := data[key]
+		if !ok || raw == nil || fmt.Sprint(raw) == "" {
+			continue
+		}
+		text := fmt.Sprint(raw)
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Int, reflect.Int64:
+			n, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				return faults, fmt.Errorf("fault %s: %w", key, err)
+			}
+			field.SetInt(n)
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return faults, fmt.Errorf("fault %s: %w", key, err)
+			}
+			field.SetFloat(f)
+		}
+	}
+	return faults, nil
 }
 
 // ParseConfig reads the configuration file and returns a GwAutoConfig object
@@ -34,3 +34,144 @@ func TestParseConfig(t *testing.T) {
 	config.InitConfigMap()
 	assert.Equal(t, len(config.SimulatorMap), 2)
 }
+
+func TestParseConfigProtobuf(t *testing.T) {
+	config, err := config.ParseConfig("testdata/gw-auto-protobuf.toml")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	assert.Len(t, config.Simulators, 2)
+	assert.Equal(t, "protobuf", config.Simulators[0].Protocol)
+	assert.Equal(t, []string{"order.proto"}, config.Simulators[0].Protobuf.ProtoFiles)
+	assert.Equal(t, []string{"pkg/codec/testdata"}, config.Simulators[0].Protobuf.ImportPaths)
+	assert.Equal(t, "order.Envelope", config.Simulators[0].Protobuf.Message)
+	assert.Equal(t, 0, config.Simulators[0].Protobuf.LengthPrefix)
+	assert.Equal(t, 4, config.Simulators[1].Protobuf.LengthPrefix)
+}
+
+func TestParseConfigJSON(t *testing.T) {
+	config, err := config.ParseConfig("testdata/gw-auto-json.toml")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	assert.Len(t, config.Simulators, 2)
+	assert.Equal(t, "ws", config.Simulators[0].Communication)
+	assert.Equal(t, "json-lines", config.Simulators[0].Protocol)
+	assert.Equal(t, "tcp", config.Simulators[1].Communication)
+}
+
+func TestParseConfigUDP(t *testing.T) {
+	config, err := config.ParseConfig("testdata/gw-auto-udp.toml")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	assert.Len(t, config.Simulators, 2)
+	assert.Equal(t, "udp", config.Simulators[0].Communication)
+	assert.Equal(t, "eth0", config.Simulators[0].Interface)
+	assert.Equal(t, "239.1.1.1:9007", config.Simulators[1].ServerAddress)
+}
+
+func TestParseConfigHTTP(t *testing.T) {
+	config, err := config.ParseConfig("testdata/gw-auto-http.toml")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	assert.Len(t, config.Simulators, 2)
+	assert.Equal(t, "http", config.Simulators[0].Communication)
+	assert.Empty(t, config.Simulators[0].Protocol)
+	assert.Equal(t, ":9010", config.Simulators[1].ListenAddress)
+}
+
+func TestParseConfigReconnect(t *testing.T) {
+	config, err := config.ParseConfig("testdata/gw-auto-reconnect.toml")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	assert.Len(t, config.Simulators, 1)
+	reconnect := config.Simulators[0].Reconnect
+	assert.Equal(t, -1, reconnect.Retries)
+	assert.Equal(t, 200, reconnect.BackoffMs)
+	assert.Equal(t, 3000, reconnect.MaxBackoffMs)
+	assert.True(t, reconnect.AutoReconnect)
+}
+
+func TestParseFaultConfig(t *testing.T) {
+	faults, err := config.ParseFaultConfig(map[string]interface{}{
+		"StepId": "faults_001", "latency_ms": "50", "split_bytes": 3, "corrupt_rate": "0.01", "seed": "",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, config.FaultConfig{LatencyMs: 50, SplitBytes: 3, CorruptRate: 0.01}, faults)
+
+	_, err = config.ParseFaultConfig(map[string]interface{}{"latency_ms": "slow"})
+	assert.ErrorContains(t, err, "latency_ms")
+}
+
+func TestParseConfigProxy(t *testing.T) {
+	config, err := config.ParseConfig("testdata/gw-auto-proxy.toml")
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	assert.Len(t, config.Simulators, 1)
+	assert.Equal(t, "proxy", config.Simulators[0].Type)
+	assert.Equal(t, "gateway:9003", config.Simulators[0].ServerAddress)
+	assert.Equal(t, "szse_capture.jsonl", config.Simulators[0].CaptureFile)
+}
+
+func TestParseConfigDifferential(t *testing.T) {
+	conf, err := config.ParseConfig("testdata/gw-auto-differential.toml")
+	assert.NoError(t, err)
+	assert.Len(t, conf.Differential.Targets, 2)
+	assert.Equal(t, []string{"TransactTime"}, conf.Differential.Ignore)
+
+	baseline := conf.ForTarget(conf.Differential.Targets[0])
+	assert.Equal(t, ":9003", baseline.SimulatorMap["szse_bin_tgw_1"].ListenAddress)
+	assert.Equal(t, "localhost:9001", baseline.SimulatorMap["szse_bin_oms_1"].ServerAddress)
+
+	candidate := conf.ForTarget(conf.Differential.Targets[1])
+	assert.Equal(t, ":9103", candidate.SimulatorMap["szse_bin_tgw_1"].ListenAddress)
+	assert.Equal(t, "localhost:9101", candidate.SimulatorMap["szse_bin_oms_1"].ServerAddress)
+	assert.Equal(t, ":9003", conf.Simulators[0].ListenAddress)
+}
+
+func TestForWorker(t *testing.T) {
+	conf := config.GwAutoConfig{
+		Simulators: []config.SimulatorConfig{
+			{Name: "tgw", Type: "tgw", ListenAddress: ":9003"},
+			{Name: "oms", Type: "oms", ServerAddress: "localhost:9001"},
+			{Name: "ws", Type: "oms", Communication: "ws", ServerAddress: "ws://localhost:9002/trade"},
+			{Name: "proxy", Type: "proxy", ListenAddress: "127.0.0.1:9004", ServerAddress: "[::1]:9005", CaptureFile: "out/capture.jsonl"},
+		},
+		Parallel: config.ParallelConfig{PortStep: 10},
+		Latency:  config.LatencyConfig{CorrelationField: "OrderID"},
+	}
+	worker, err := conf.ForWorker(0)
+	assert.NoError(t, err)
+	assert.Equal(t, conf.Simulators, worker.Simulators)
+
+	worker, err = conf.ForWorker(2)
+	assert.NoError(t, err)
+	assert.Equal(t, ":9023", worker.SimulatorMap["tgw"].ListenAddress)
+	assert.Equal(t, "localhost:9021", worker.SimulatorMap["oms"].ServerAddress)
+	assert.Equal(t, "ws://localhost:9022/trade", worker.SimulatorMap["ws"].ServerAddress)
+	assert.Equal(t, "127.0.0.1:9024", worker.SimulatorMap["proxy"].ListenAddress)
+	assert.Equal(t, "[::1]:9025", worker.SimulatorMap["proxy"].ServerAddress)
+	assert.Equal(t, "out/capture-2.jsonl", worker.SimulatorMap["proxy"].CaptureFile)
+	assert.Equal(t, "OrderID", worker.Latency.CorrelationField)
+	assert.Equal(t, ":9003", conf.Simulators[0].ListenAddress)
+
+	conf.Simulators[0].ListenAddress = "no port"
+	_, err = conf.ForWorker(1)
+	assert.Error(t, err)
+}
+
+func TestForWorkerEphemeralPort(t *testing.T) {
+	conf := config.GwAutoConfig{
+		Simulators: []config.SimulatorConfig{
+			{Name: "tgw", Type: "tgw", ListenAddress: "127.0.0.1:0"},
+		},
+		Parallel: config.ParallelConfig{PortStep: 10},
+	}
+	worker, err := conf.ForWorker(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:0", worker.SimulatorMap["tgw"].ListenAddress, "port 0 is picked by the system for every worker")
+}
@@ -1,31 +1,55 @@
 package executor
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
 	log "github.com/sirupsen/logrus"
 	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	gt_codec "github.com/xinchentechnote/gt-auto/pkg/codec"
 	"github.com/xinchentechnote/gt-auto/pkg/config"
 	"github.com/xinchentechnote/gt-auto/pkg/tcp"
 	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
 )
 
 // CaseExecutor is responsible for executing test cases.
 type CaseExecutor struct {
-	Cases        []*testcase.TestCase
+	Cases []*testcase.TestCase
+	// Sections are the setup and teardown steps run around the suite and each case
+	Sections     testcase.Sections
 	Config       config.GwAutoConfig
 	simulatorMap map[string]tcp.Simulator[codec.BinaryCodec]
+	// lifecycleMarks is the time of the last lifecycle action on each simulator
+	lifecycleMarks map[string]time.Time
+	// compared are the simulators whose received messages are kept for a differential
+	// comparison instead of being validated
+	compared map[string]bool
+	// FailFast aborts the suite at the first failed step without an OnFailure policy
+	FailFast bool
+	// suiteAborted is set once a step aborted the suite, shared by the executors of a parallel run
+	suiteAborted *atomic.Bool
+	// retrying counts the Retry blocks being run, whose failures are retried instead of aborting
+	retrying int
+	// inBlock counts the control blocks being run, whose steps pause for their sleep_ms only
+	inBlock int
 }
 
 // NewCaseExecutor creates a new CaseExecutor instance.
 func NewCaseExecutor(config config.GwAutoConfig, cases []*testcase.TestCase) *CaseExecutor {
+	sections, cases := testcase.SplitSections(cases)
 	executor := &CaseExecutor{
-		Cases:        cases,
-		Config:       config,
-		simulatorMap: make(map[string]tcp.Simulator[codec.BinaryCodec]),
+		Cases:          cases,
+		Sections:       sections,
+		Config:         config,
+		simulatorMap:   make(map[string]tcp.Simulator[codec.BinaryCodec]),
+		lifecycleMarks: make(map[string]time.Time),
+		suiteAborted:   new(atomic.Bool),
 	}
 	executor.initSimulator()
 	return executor
@@ -51,24 +75,88 @@ func (e *CaseExecutor) initSimulator() {
 
 // Execute runs the test cases.
 func (e *CaseExecutor) Execute() {
-	time.Sleep(5 * time.Second)
+	e.run()
+	e.showSection(e.Sections.SuiteSetup)
+	for i, c := range e.Cases {
+		e.showResult(i, c)
+	}
+	e.showSection(e.Sections.SuiteTeardown)
+}
+
+func (e *CaseExecutor) run() {
 	if e.Cases == nil {
 		return
 	}
+	time.Sleep(5 * time.Second)
+	if setup := e.Sections.SuiteSetup; setup != nil {
+		log.Infof("Start to execute suite setup: %s\n", setup.CaseTitle)
+		e.executeSteps(setup)
+	}
+	if teardown := e.Sections.SuiteTeardown; teardown != nil {
+		defer func() {
+			log.Infof("Start to execute suite teardown: %s\n", teardown.CaseTitle)
+			teardown.Variables = e.suiteVariables()
+			e.executeSteps(teardown)
+		}()
+	}
 	for i, c := range e.Cases {
+		if e.isSuiteAborted() {
+			log.Warnf("Skip case: %d, %s - %s, the suite was aborted\n", i, c.CaseID, c.CaseTitle)
+			skipSteps(c, 0)
+			continue
+		}
 		e.executeCase(i, c)
 	}
+}
 
-	for i, c := range e.Cases {
-		e.showResult(i, c)
+// suiteVariables returns a copy of the variables captured by the suite setup
+func (e *CaseExecutor) suiteVariables() map[string]string {
+	variables := make(map[string]string)
+	if e.Sections.SuiteSetup != nil {
+		for k, v := range e.Sections.SuiteSetup.Variables {
+			variables[k] = v
+		}
 	}
+	return variables
 }
 
 func (e *CaseExecutor) showResult(index int, c *testcase.TestCase) {
 	log.Infof("Show to case result: %d, %s - %s\n", index, c.CaseID, c.CaseTitle)
-	for _, result := range c.ValidateResults {
-		if !result.Passed {
-			log.Errorf("Show to case result: %d, %s❌", result.Index, result.StepID)
+	if c.Setup != nil {
+		showValidateResults(testcase.CaseSetup, c.Setup.ValidateResults)
+	}
+	showValidateResults("case", c.ValidateResults)
+	if c.Teardown != nil {
+		showValidateResults(testcase.CaseTeardown, c.Teardown.ValidateResults)
+	}
+	for _, step := range c.Steps {
+		if step.Hop != "" {
+			log.Infof("Show to case latency: %s, %s: %s", step.StepID, step.Hop, step.Latency)
+		}
+	}
+}
+
+// showSection shows the results of a suite setup or teardown, if the suite has one
+func (e *CaseExecutor) showSection(section *testcase.TestCase) {
+	if section == nil {
+		return
+	}
+	log.Infof("Show to %s result: %s\n", section.CaseID, section.CaseTitle)
+	showValidateResults(section.CaseID, section.ValidateResults)
+}
+
+// showValidateResults shows the result of every step with its status and duration, then counts them by status
+func showValidateResults(kind string, results []testcase.StepValidateResult) {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+		switch result.Status {
+		case testcase.StatusSkipped:
+			log.Warnf("Show to %s result: %d-%s:⏭ skipped", kind, result.Index, result.StepID)
+		case testcase.StatusError:
+			log.Errorf("Show to %s result: %d, %s❗ error after %s: %s", kind, result.Index, result.StepID, result.Duration, result.Error)
+		case testcase.StatusFailed:
+			log.Errorf("Show to %s result: %d, %s❌ failed after %s", kind, result.Index, result.StepID, result.Duration)
 			table := tablewriter.NewWriter(os.Stdout)
 			table.SetHeader([]string{"Path", "Expected", "Actual"})
 			for _, diff := range result.Detail.Diffs {
@@ -79,28 +167,204 @@ func (e *CaseExecutor) showResult(index int, c *testcase.TestCase) {
 				})
 			}
 			table.Render()
-		} else {
-			log.Infof("Show to case result: %d-%s:✅", result.Index, result.StepID)
+		default:
+			log.Infof("Show to %s result: %d-%s:✅ %s", kind, result.Index, result.StepID, result.Duration)
 		}
 	}
+	log.Infof("Show to %s summary: %d passed, %d failed, %d error, %d skipped", kind, counts[testcase.StatusPassed],
+		counts[testcase.StatusFailed], counts[testcase.StatusError], counts[testcase.StatusSkipped])
 }
 
 func (e *CaseExecutor) executeCase(index int, c *testcase.TestCase) {
 	log.Infof("Start to execute case: %d, %s - %s\n", index, c.CaseID, c.CaseTitle)
-	for i, step := range c.Steps {
-		e.executeStep(i, c, &step)
+	// the case sections share the variables of the case, seeded by the suite setup
+	c.Variables = e.suiteVariables()
+	if e.Sections.Teardown != nil {
+		defer func() {
+			log.Infof("Start to execute case teardown: %d, %s\n", index, c.CaseID)
+			c.Teardown = e.Sections.Teardown.Clone()
+			c.Teardown.Variables = c.Variables
+			e.executeSteps(c.Teardown)
+		}()
+	}
+	if e.Sections.Setup != nil {
+		log.Infof("Start to execute case setup: %d, %s\n", index, c.CaseID)
+		c.Setup = e.Sections.Setup.Clone()
+		c.Setup.Variables = c.Variables
+		if !e.executeSteps(c.Setup) {
+			log.Warnf("Skip case: %d, %s, its setup was aborted\n", index, c.CaseID)
+			skipSteps(c, 0)
+			return
+		}
+	}
+	e.executeSteps(c)
+}
+
+// executeSteps runs the steps of c in order, following its control steps, see isControlAction.
+// It returns false when a failed step aborted c, see afterFailure, the teardown sections still run.
+func (e *CaseExecutor) executeSteps(c *testcase.TestCase) bool {
+	return e.executeBlock(c, 0, len(c.Steps))
+}
+
+// runStep runs a step, records its result when it recorded no validation and reports whether it failed:
+// it returned an error or panicked, recorded as an error result of the step, or failed a validation.
+func (e *CaseExecutor) runStep(index int, c *testcase.TestCase, step *testcase.TestStep) (failed bool, panicked bool) {
+	mark := len(c.ValidateResults)
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Step %s of %s panicked: %v", step.StepID, c.CaseID, r)
+			c.AddErrorResult(index, step.StepID, fmt.Errorf("panic: %v", r))
+			failed, panicked = true, true
+		}
+		failed = finishResults(c, mark, start) || failed
+	}()
+	if err := e.executeStep(index, c, step); err != nil {
+		log.Errorf("Step %s of %s failed: %s", step.StepID, c.CaseID, err)
+		c.AddErrorResult(index, step.StepID, err)
+	} else if len(c.ValidateResults) == mark {
+		c.AddValidateResult(index, step.StepID, validate.CompareResult{Equal: true})
+	}
+	return false, false
+}
+
+// finishResults sets the duration of the results recorded since mark by a step started at start
+// and reports whether one of them did not pass
+func finishResults(c *testcase.TestCase, mark int, start time.Time) bool {
+	elapsed := time.Since(start)
+	failed := false
+	for i := range c.ValidateResults[mark:] {
+		result := &c.ValidateResults[mark+i]
+		result.Duration = elapsed
+		failed = failed || !result.Passed
+	}
+	return failed
+}
+
+// failurePolicy returns what a failure of the step does to the run: its OnFailure,
+// or when not given testcase.OnFailureAbortSuite with FailFast and testcase.OnFailureContinue otherwise.
+func (e *CaseExecutor) failurePolicy(step *testcase.TestStep) string {
+	switch {
+	case step.OnFailure != "":
+		return step.OnFailure
+	case e.FailFast:
+		return testcase.OnFailureAbortSuite
+	default:
+		return testcase.OnFailureContinue
+	}
+}
+
+// afterFailure applies the failure policy of a failed step of c and returns whether c goes on.
+// An aborted case records its steps from next as skipped, an aborted suite skips its remaining cases.
+// A failure within a Retry block is retried instead, unless the step panicked, which always aborts the case.
+func (e *CaseExecutor) afterFailure(c *testcase.TestCase, index int, next int, panicked bool) bool {
+	if e.retrying > 0 && !panicked {
+		return true
+	}
+	step := &c.Steps[index]
+	policy := e.failurePolicy(step)
+	if panicked && policy == testcase.OnFailureContinue {
+		policy = testcase.OnFailureAbortCase
+	}
+	switch policy {
+	case testcase.OnFailureAbortSuite:
+		log.Errorf("Step %s of %s failed, aborting the suite", step.StepID, c.CaseID)
+		e.abortSuite()
+	case testcase.OnFailureAbortCase:
+		log.Errorf("Step %s of %s failed, aborting the case", step.StepID, c.CaseID)
+	default:
+		return true
+	}
+	skipSteps(c, next)
+	return false
+}
+
+// skipSteps records the steps of c from index from that have not run as skipped: aborting within a Repeat
+// skips the rest of the iteration and the steps after the block, the steps run by earlier iterations keep their results
+func skipSteps(c *testcase.TestCase, from int) {
+	ran := make(map[int]bool, len(c.ValidateResults))
+	for _, r := range c.ValidateResults {
+		ran[r.Index] = true
+	}
+	for i := from; i < len(c.Steps); i++ {
+		if !ran[i] {
+			c.AddSkippedResult(i, c.Steps[i].StepID)
+		}
+	}
+}
+
+// skipRange records the steps of c from index from up to to as skipped
+func skipRange(c *testcase.TestCase, from, to int) {
+	for i := from; i < to; i++ {
+		c.AddSkippedResult(i, c.Steps[i].StepID)
+	}
+}
+
+// abortSuite skips the cases that have not started, of every executor sharing the abort flag
+func (e *CaseExecutor) abortSuite() {
+	if e.suiteAborted == nil {
+		e.suiteAborted = new(atomic.Bool)
+	}
+	e.suiteAborted.Store(true)
+}
+
+func (e *CaseExecutor) isSuiteAborted() bool {
+	return e.suiteAborted != nil && e.suiteAborted.Load()
+}
+
+// captureVariables records the received value of every captured field
+func captureVariables(c *testcase.TestCase, actual codec.BinaryCodec, captures map[string]string) {
+	if len(captures) == 0 {
+		return
+	}
+	fields, err := gt_codec.MessageToMap(actual)
+	if err != nil {
+		log.Errorf("Capture failed: %s", err)
+		return
+	}
+	for field, name := range captures {
+		value, ok := testcase.LookupField(fields, field)
+		if !ok {
+			log.Warnf("Capture %s: no field %s received", name, field)
+			continue
+		}
+		c.Capture(name, value)
+		log.Infof("Captured %s = %v", name, value)
 	}
 }
 
-func (e *CaseExecutor) executeStep(index int, c *testcase.TestCase, step *testcase.TestStep) {
+// parseTestTool splits a TestTool of the form name@session, session selects
+// a connection of a SessionSimulator by ID, CompID or index.
+func parseTestTool(testTool string) (string, string) {
+	name, session, _ := strings.Cut(testTool, "@")
+	return name, session
+}
+
+// stepPause returns how long to wait before running step: a second, or its sleep_ms within a control block
+// so that a repeated step does not pay the second on every iteration
+func (e *CaseExecutor) stepPause(step *testcase.TestStep) time.Duration {
+	if e.inBlock == 0 {
+		return 1000 * time.Millisecond
+	}
+	pause, _ := durationMs(strings.TrimSpace(step.SleepMs))
+	return pause
+}
+
+// executeStep runs a step, it returns an error when the action could not be carried out
+// and records the validations of the step otherwise.
+func (e *CaseExecutor) executeStep(index int, c *testcase.TestCase, step *testcase.TestStep) error {
 	log.Infof("Start to execute step: %d, %s\n", index, step.StepID)
-	var simulator = e.simulatorMap[step.TestTool]
+	if step.ActionType == "ExpectLatency" {
+		return expectLatency(index, c, step)
+	}
+	name, session := parseTestTool(step.TestTool)
+	var simulator = e.simulatorMap[name]
 	if nil == simulator {
-		conf := e.Config.SimulatorMap[step.TestTool]
+		conf := e.Config.SimulatorMap[name]
 		var err error
 		simulator, err = tcp.CreateSimulator[codec.BinaryCodec](conf)
 		if nil != err {
-			return
+			return fmt.Errorf("cannot create simulator %s: %w", name, err)
 		}
 		go func() {
 			err = simulator.Start()
@@ -108,41 +372,91 @@ func (e *CaseExecutor) executeStep(index int, c *testcase.TestCase, step *testca
 				return
 			}
 		}()
-		e.simulatorMap[step.TestTool] = simulator
+		e.simulatorMap[name] = simulator
+	}
+	sessionSimulator, ok := simulator.(tcp.SessionSimulator[codec.BinaryCodec])
+	if session != "" && !ok {
+		return fmt.Errorf("simulator %s does not support sessions: %s", name, step.TestTool)
+	}
+	time.Sleep(e.stepPause(step))
+	if isLifecycleAction(step.ActionType) {
+		return e.executeLifecycleStep(index, c, step, name, session, simulator)
+	}
+	if reporter, ok := simulator.(tcp.StateReporter); ok {
+		if state := waitConnected(reporter, defaultExpectTimeout); state != tcp.StateConnected {
+			log.Warnf("Simulator %s is %s", name, state)
+		}
 	}
-	time.Sleep(1000 * time.Millisecond)
 	switch step.ActionType {
 	case "Send":
-		step.TestDatas["MsgType"] = step.MsgType
-		log.Info("Send data: ", step.TestDatas)
-		err := simulator.SendFromJSON(step.TestDatas)
+		data := c.Substitute(step.TestDatas)
+		data["MsgType"] = step.MsgType
+		log.Info("Send data: ", data)
+		var err error
+		if session != "" {
+			err = sessionSimulator.SendFromJSONTo(session, data)
+		} else {
+			err = simulator.SendFromJSON(data)
+		}
 		if nil != err {
-			log.Errorf("Send failed:%s", err)
+			return fmt.Errorf("send failed: %w", err)
 		}
+		step.FrameTime = frameTime(simulator, true)
 	case "Receive":
-		step.TestDatas["MsgType"] = step.MsgType
-		expect, err := simulator.GetCodec().JSONToStruct(step.TestDatas)
+		expected, ignored, captures := testcase.Expectations(c.Substitute(step.TestDatas))
+		expected["MsgType"] = step.MsgType
+		expect, err := simulator.GetCodec().JSONToStruct(expected)
 		if nil != err {
-			//TODO
-			log.Error("Expect JsonToStruct failed: ", err)
-			return
+			return fmt.Errorf("expect JsonToStruct failed: %w", err)
 		}
 		step.SetExpect(expect)
-		actual, err := simulator.Receive()
-		if nil != err {
-			//TODO
+		var actual codec.BinaryCodec
+		if session != "" {
+			actual, err = sessionSimulator.ReceiveFrom(session)
+		} else {
+			actual, err = simulator.Receive()
+		}
+		var checksumErr *gt_codec.ChecksumError
+		if errors.As(err, &checksumErr) {
+			log.Error("Receive checksum fault: ", err)
+		} else if nil != err {
+			// nothing received fails the comparison like a wrong message would, the step did run
 			log.Error("Receive failed: ", err)
-			return
+			step.SetActual(nil)
+			if step.VerifyRequired && !e.compared[name] {
+				c.AddValidateResult(index, step.StepID, validate.CompareResult{Diffs: []validate.Diff{{
+					Path:   "Message",
+					Expect: step.MsgType,
+					Actual: "none received: " + err.Error(),
+				}}})
+			}
+			return nil
+		}
+		step.FrameTime = frameTime(simulator, false)
+		measureLatency(c, index, name, actual, e.correlationField())
+		captureVariables(c, actual, captures)
+		step.SetActual(actual)
+		if e.compared[name] {
+			log.Info("Received for comparison: ", actual)
+			return nil
 		}
 		if step.VerifyRequired {
 			log.Info("TestData data: ", step.TestDatas)
 			log.Info("Actual data: ", actual)
-			step.SetActual(actual)
 			log.Info("Expected data: ", step.Expect)
-			result := step.Validate()
+			result := step.Validate().Ignore(ignored...)
+			if checksumErr != nil {
+				result.Equal = false
+				result.Diffs = append(result.Diffs, validate.Diff{
+					Path:   "Checksum",
+					Expect: checksumErr.Expected,
+					Actual: checksumErr.Actual,
+				})
+			}
 			c.AddValidateResult(index, step.StepID, result)
 		}
 	default:
-		log.Warnf("Unknown action type: %s", step.ActionType)
+		return fmt.Errorf("unknown action type: %s", step.ActionType)
 	}
+	return nil
 }
@@ -0,0 +1,155 @@
+package executor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	gt_codec "github.com/xinchentechnote/gt-auto/pkg/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+// silentSimulator never receives anything
+type silentSimulator struct {
+	codec gt_codec.MessageCodec
+}
+
+func (s *silentSimulator) Start() error                                      { return nil }
+func (s *silentSimulator) Close() error                                      { return nil }
+func (s *silentSimulator) GetCodec() gt_codec.MessageCodec                   { return s.codec }
+func (s *silentSimulator) Send(ext interface{}, msg codec.BinaryCodec) error { return nil }
+func (s *silentSimulator) SendFromJSON(message map[string]interface{}) error { return nil }
+func (s *silentSimulator) Receive() (codec.BinaryCodec, error)               { return nil, errors.New("empty queue") }
+
+func skipped(c *testcase.TestCase) []string {
+	var steps []string
+	for _, r := range c.ValidateResults {
+		if r.Status == testcase.StatusSkipped {
+			steps = append(steps, r.StepID)
+		}
+	}
+	return steps
+}
+
+func TestRunStepRecordsEveryStep(t *testing.T) {
+	e := &CaseExecutor{}
+	c := controlCase(
+		control("bad", "ExpectLatency", map[string]interface{}{}),
+		control("unknown", "Shout", map[string]interface{}{}),
+		expectWithin("fast", "5"),
+	)
+	failed, _ := e.runStep(1, c, &c.Steps[1])
+	assert.True(t, failed)
+	failed, _ = e.runStep(3, c, &c.Steps[3])
+	assert.False(t, failed)
+
+	assert.Len(t, c.ValidateResults, 2)
+	assert.Equal(t, testcase.StatusError, c.ValidateResults[0].Status)
+	assert.Equal(t, "no WithinMs", c.ValidateResults[0].Error)
+	assert.Equal(t, testcase.StatusPassed, c.ValidateResults[1].Status)
+
+	c.AddErrorResult(2, "unknown", errors.New("boom"))
+	assert.False(t, c.ValidateResults[2].Passed)
+}
+
+func TestOnFailure(t *testing.T) {
+	failing := func(policy string) testcase.TestStep {
+		step := expectWithin("slow", "0.5")
+		step.OnFailure = policy
+		return step
+	}
+	for policy, expected := range map[string][]string{
+		testcase.OnFailureContinue:   {"slow failed", "fast passed"},
+		testcase.OnFailureAbortCase:  {"slow failed", "fast skipped"},
+		testcase.OnFailureAbortSuite: {"slow failed", "fast skipped"},
+	} {
+		e := &CaseExecutor{}
+		c := controlCase(failing(policy), expectWithin("fast", "5"))
+		e.executeSteps(c)
+		assert.Equal(t, expected, statuses(c), policy)
+		assert.Equal(t, policy == testcase.OnFailureAbortSuite, e.isSuiteAborted(), policy)
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	e := &CaseExecutor{FailFast: true}
+	first := controlCase(expectWithin("slow", "0.5"), expectWithin("fast", "5"))
+	e.executeCase(0, first)
+	assert.True(t, e.isSuiteAborted())
+	assert.Equal(t, []string{"fast"}, skipped(first))
+
+	e = &CaseExecutor{FailFast: true}
+	step := expectWithin("slow", "0.5")
+	step.OnFailure = testcase.OnFailureContinue
+	c := controlCase(step, expectWithin("fast", "5"))
+	e.executeCase(0, c)
+	assert.False(t, e.isSuiteAborted(), "the policy of a step overrides fail-fast")
+}
+
+func TestOnFailureWithinRetry(t *testing.T) {
+	e := &CaseExecutor{}
+	step := expectWithin("slow", "0.5")
+	step.OnFailure = testcase.OnFailureAbortCase
+	retry := control("retry", "Retry", map[string]interface{}{"TimeoutMs": "150", "Steps": "1"})
+	retry.OnFailure = testcase.OnFailureAbortCase
+	c := controlCase(retry, step, expectWithin("fast", "5"))
+	e.executeSteps(c)
+	assert.Equal(t, []string{"retry failed", "slow failed", "fast skipped"}, statuses(c),
+		"the block is retried, then the retry aborts the case")
+}
+
+func TestOnFailureWithinRepeat(t *testing.T) {
+	e := &CaseExecutor{}
+	step := expectWithin("slow", "0.5")
+	step.OnFailure = testcase.OnFailureAbortCase
+	c := controlCase(
+		control("repeat", "Repeat", map[string]interface{}{"Times": "3", "Steps": "4"}),
+		expectWithin("fast", "5"),
+		control("second", "If", map[string]interface{}{"Condition": "Iteration == 2"}),
+		step,
+		expectWithin("later", "5"),
+		expectWithin("after", "5"),
+	)
+	e.executeSteps(c)
+	assert.Equal(t, []string{"repeat passed",
+		"fast passed", "second passed", "slow skipped", "later passed",
+		"fast passed", "second passed", "slow failed",
+		"after skipped"}, statuses(c), "the second iteration aborts the case, the first one keeps its results")
+}
+
+func TestReceiveNothingFails(t *testing.T) {
+	jsonLines, err := gt_codec.GetDefaultMessageCodecFactory().GetCodec(gt_codec.JSONLines)
+	require.NoError(t, err)
+	e := &CaseExecutor{
+		FailFast:     true,
+		simulatorMap: map[string]tcp.Simulator[codec.BinaryCodec]{"tgw": &silentSimulator{codec: jsonLines}},
+	}
+	c := &testcase.TestCase{CaseID: "silent_001", Steps: []testcase.TestStep{{
+		StepID: "recv", ActionType: "Receive", TestTool: "tgw", MsgType: "NewOrder", VerifyRequired: true,
+		TestDatas: map[string]interface{}{"ClOrdID": "c1"},
+	}}}
+	e.executeCase(0, c)
+	require.Len(t, c.ValidateResults, 1)
+	result := c.ValidateResults[0]
+	assert.Equal(t, testcase.StatusFailed, result.Status, "a missing message is a failed comparison, not an error")
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "Message", result.Detail.Diffs[0].Path)
+	assert.True(t, e.isSuiteAborted(), "with fail-fast a failed comparison aborts the suite like any failure")
+}
+
+func TestSetupAbortSkipsCase(t *testing.T) {
+	setup := &testcase.TestCase{CaseID: testcase.CaseSetup, Steps: []testcase.TestStep{
+		control("bad", "Repeat", map[string]interface{}{"Times": "never"}),
+	}}
+	setup.Steps[0].OnFailure = testcase.OnFailureAbortCase
+	teardown := &testcase.TestCase{CaseID: testcase.CaseTeardown, Steps: []testcase.TestStep{expectWithin("td", "5")}}
+	e := &CaseExecutor{Sections: testcase.Sections{Setup: setup, Teardown: teardown}}
+	c := controlCase(expectWithin("fast", "5"))
+	e.executeCase(0, c)
+	assert.Equal(t, []string{"recv", "fast"}, skipped(c))
+	assert.Equal(t, testcase.StatusFailed, c.Setup.ValidateResults[0].Status)
+	assert.Len(t, c.Teardown.ValidateResults, 1, "the teardown still runs")
+}
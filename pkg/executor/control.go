@@ -0,0 +1,242 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+// iterationVariable is the variable holding the 1-based iteration of the innermost Repeat
+const iterationVariable = "Iteration"
+
+// retryInterval is the pause between two attempts of a Retry block
+const retryInterval = 100 * time.Millisecond
+
+// conditionPattern matches a condition of the form Variable op value
+var conditionPattern = regexp.MustCompile(`^\s*(?:\$\{(\w+)\}|(\w+))\s*(==|!=|<=|>=|<|>)\s*(.*?)\s*$`)
+
+// isControlAction reports whether actionType controls the steps that follow it rather than acting on a simulator.
+// Each control step owns the block of the next Steps steps, 1 when not given:
+// Repeat runs its block Times times with ${Iteration} set to the iteration,
+// If runs its block only when Condition, e.g. OrdStatus == 8, holds on the captured variables,
+// Retry runs its block again until all its validations pass or TimeoutMs has elapsed.
+// Times, Condition, TimeoutMs and Steps come from the test data sheet of the control step, or inline
+// in its test_data column, e.g. Times=3;Steps=2. The steps of a block pause for their sleep_ms only.
+// The steps of a block that does not run are recorded as skipped: when the control step is malformed, which fails it,
+// when Times is 0 or when Condition does not hold. A Retry block that did not pass fails the Retry step.
+func isControlAction(actionType string) bool {
+	switch actionType {
+	case "Repeat", "If", "Retry":
+		return true
+	}
+	return false
+}
+
+// executeBlock runs the steps of c from index from up to to, it returns false when a failed step
+// aborted the case, see afterFailure.
+func (e *CaseExecutor) executeBlock(c *testcase.TestCase, from, to int) bool {
+	for i := from; i < to; i++ {
+		step := &c.Steps[i]
+		if !isControlAction(step.ActionType) {
+			if failed, panicked := e.runStep(i, c, step); failed && !e.afterFailure(c, i, i+1, panicked) {
+				return false
+			}
+			continue
+		}
+		size := 1
+		if v, ok := step.TestDatas["Steps"]; ok {
+			n, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(v)))
+			if err != nil || n < 0 {
+				failControlStep(i, c, step, "Steps", "a number of steps", fmt.Sprint(v))
+				if !e.afterFailure(c, i, i+1, false) {
+					return false
+				}
+				continue
+			}
+			size = n
+		}
+		end := min(i+1+size, to)
+		mark, start := len(c.ValidateResults), time.Now()
+		goOn := e.executeControlStep(i, c, step, i+1, end)
+		finishControlResult(c, i, mark, start)
+		if !goOn {
+			return false
+		}
+		i = end - 1
+	}
+	return true
+}
+
+func (e *CaseExecutor) executeControlStep(index int, c *testcase.TestCase, step *testcase.TestStep, from, to int) bool {
+	log.Infof("Start to execute step: %d, %s\n", index, step.StepID)
+	e.inBlock++
+	defer func() { e.inBlock-- }()
+	switch step.ActionType {
+	case "Repeat":
+		times, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(step.TestDatas["Times"])))
+		if err != nil || times < 0 {
+			failControlStep(index, c, step, "Times", "a number of times", fmt.Sprint(step.TestDatas["Times"]))
+			skipRange(c, from, to)
+			return e.afterFailure(c, index, to, false)
+		}
+		if times == 0 {
+			skipRange(c, from, to)
+		}
+		outer, nested := c.Variables[iterationVariable]
+		for k := 1; k <= times; k++ {
+			c.Capture(iterationVariable, k)
+			log.Infof("%s %s: iteration %d of %d", step.ActionType, step.StepID, k, times)
+			if !e.executeBlock(c, from, to) {
+				return false
+			}
+		}
+		if nested {
+			c.Capture(iterationVariable, outer)
+		} else {
+			delete(c.Variables, iterationVariable)
+		}
+	case "If":
+		condition := fmt.Sprint(step.TestDatas["Condition"])
+		holds, err := evaluateCondition(condition, c.Variables)
+		if err != nil {
+			failControlStep(index, c, step, "Condition", "a condition on a captured variable", err.Error())
+			skipRange(c, from, to)
+			return e.afterFailure(c, index, to, false)
+		}
+		log.Infof("%s %s: %s is %t", step.ActionType, step.StepID, condition, holds)
+		if !holds {
+			skipRange(c, from, to)
+			return true
+		}
+		return e.executeBlock(c, from, to)
+	case "Retry":
+		timeout := defaultExpectTimeout
+		if ms, ok := durationMs(step.TestDatas["TimeoutMs"]); ok {
+			timeout = ms
+		}
+		passed, attempts, ok := e.retryBlock(c, from, to, timeout)
+		if !ok {
+			return false
+		}
+		if passed {
+			log.Infof("%s %s: passed after %d attempts", step.ActionType, step.StepID, attempts)
+			return true
+		}
+		log.Errorf("%s %s: not passed within %s after %d attempts", step.ActionType, step.StepID, timeout, attempts)
+		failControlStep(index, c, step, "Retry", fmt.Sprintf("passed within %s", timeout), fmt.Sprintf("not passed after %d attempts", attempts))
+		return e.afterFailure(c, index, to, false)
+	}
+	return true
+}
+
+// retryBlock runs the block until it passed or timeout elapsed, keeping only the results of the last attempt.
+// It returns whether the block passed, the number of attempts and false when a step aborted the case.
+func (e *CaseExecutor) retryBlock(c *testcase.TestCase, from, to int, timeout time.Duration) (bool, int, bool) {
+	e.retrying++
+	defer func() { e.retrying-- }()
+	deadline := time.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		mark := len(c.ValidateResults)
+		if !e.executeBlock(c, from, to) {
+			return false, attempt, false
+		}
+		if blockPassed(c.ValidateResults[mark:]) {
+			return true, attempt, true
+		}
+		if time.Now().Add(retryInterval).After(deadline) {
+			return false, attempt, true
+		}
+		c.ValidateResults = c.ValidateResults[:mark]
+		time.Sleep(retryInterval)
+	}
+}
+
+// finishControlResult places the result of the control step index started at start before the results
+// of its block, a passed result when the step did not fail
+func finishControlResult(c *testcase.TestCase, index int, mark int, start time.Time) {
+	stepID := c.Steps[index].StepID
+	result := testcase.StepValidateResult{
+		Index:  index,
+		StepID: stepID,
+		Status: testcase.StatusPassed,
+		Passed: true,
+		Detail: validate.CompareResult{Equal: true},
+	}
+	for i := mark; i < len(c.ValidateResults); i++ {
+		if r := c.ValidateResults[i]; r.Index == index && r.StepID == stepID {
+			result = r
+			c.ValidateResults = slices.Delete(c.ValidateResults, i, i+1)
+			break
+		}
+	}
+	result.Duration = time.Since(start)
+	c.ValidateResults = slices.Insert(c.ValidateResults, mark, result)
+}
+
+// blockPassed reports whether all the steps of an attempt passed
+func blockPassed(results []testcase.StepValidateResult) bool {
+	for _, r := range results {
+		if !r.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+func failControlStep(index int, c *testcase.TestCase, step *testcase.TestStep, path string, expect, actual string) {
+	log.Errorf("%s %s failed: %s is %s", step.ActionType, step.StepID, path, actual)
+	c.AddValidateResult(index, step.StepID, validate.CompareResult{Diffs: []validate.Diff{{
+		Path:   path,
+		Expect: expect,
+		Actual: actual,
+	}}})
+}
+
+// evaluateCondition evaluates a condition of the form Variable op value, op is one of == != < <= > >=.
+// Both sides are compared as numbers when they are, as strings otherwise.
+func evaluateCondition(condition string, variables map[string]string) (bool, error) {
+	m := conditionPattern.FindStringSubmatch(condition)
+	if m == nil {
+		return false, fmt.Errorf("invalid condition %q", condition)
+	}
+	name := m[1] + m[2]
+	actual, ok := variables[name]
+	if !ok {
+		return false, fmt.Errorf("no variable %s captured", name)
+	}
+	op, expected := m[3], strings.Trim(m[4], `"'`)
+	var cmp int
+	a, errA := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+	b, errB := strconv.ParseFloat(expected, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(strings.TrimSpace(actual), expected)
+	}
+	switch op {
+	case "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
@@ -0,0 +1,119 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+// controlCase returns a case whose first step, an empty Repeat, has a measured latency of 1ms, followed by steps.
+// ExpectLatency steps need no simulator, which lets the control steps run without one.
+func controlCase(steps ...testcase.TestStep) *testcase.TestCase {
+	measured := testcase.TestStep{StepID: "recv", ActionType: "Repeat", TestDatas: map[string]interface{}{"Times": "0", "Steps": "0"},
+		Hop: "oms→tgw", Latency: time.Millisecond}
+	return &testcase.TestCase{CaseID: "control_001", Steps: append([]testcase.TestStep{measured}, steps...)}
+}
+
+func control(id, action string, data map[string]interface{}) testcase.TestStep {
+	return testcase.TestStep{StepID: id, ActionType: action, TestDatas: data}
+}
+
+func expectWithin(id, ms string) testcase.TestStep {
+	return control(id, "ExpectLatency", map[string]interface{}{"ReceiveStep": "recv", "WithinMs": ms})
+}
+
+// statuses returns the step and status of every result of the case, after the result of the first step
+func statuses(c *testcase.TestCase) []string {
+	var results []string
+	for _, r := range c.ValidateResults[1:] {
+		results = append(results, r.StepID+" "+r.Status)
+	}
+	return results
+}
+
+func TestRepeat(t *testing.T) {
+	e := &CaseExecutor{}
+	c := controlCase(
+		control("repeat", "Repeat", map[string]interface{}{"Times": "3", "Steps": "2"}),
+		expectWithin("fast", "5"),
+		expectWithin("faster", "0.5"),
+		expectWithin("after", "5"),
+	)
+	e.executeSteps(c)
+	assert.Equal(t, []string{"repeat passed",
+		"fast passed", "faster failed", "fast passed", "faster failed", "fast passed", "faster failed",
+		"after passed"}, statuses(c))
+	assert.NotContains(t, c.Variables, iterationVariable)
+
+	c = controlCase(control("repeat", "Repeat", map[string]interface{}{"Times": "many"}), expectWithin("fast", "5"))
+	e.executeSteps(c)
+	assert.Equal(t, []string{"repeat failed", "fast skipped"}, statuses(c), "a bad repeat fails and its block is skipped")
+}
+
+func TestIf(t *testing.T) {
+	e := &CaseExecutor{}
+	for condition, expected := range map[string][]string{
+		"OrdStatus == 8":    {"if passed", "fast passed", "slow failed"},
+		"${OrdStatus} != 8": {"if passed", "fast skipped", "slow failed"},
+		"Missing == 8":      {"if failed", "fast skipped", "slow failed"},
+	} {
+		c := controlCase(
+			control("if", "If", map[string]interface{}{"Condition": condition}),
+			expectWithin("fast", "5"),
+			expectWithin("slow", "0.5"),
+		)
+		c.Capture("OrdStatus", 8)
+		e.executeSteps(c)
+		assert.Equal(t, expected, statuses(c), condition)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	e := &CaseExecutor{}
+	c := controlCase(
+		control("retry", "Retry", map[string]interface{}{"TimeoutMs": "250"}),
+		expectWithin("slow", "0.5"),
+		expectWithin("after", "5"),
+	)
+	start := time.Now()
+	e.executeSteps(c)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Equal(t, []string{"retry failed", "slow failed", "after passed"}, statuses(c), "only the last attempt is kept")
+	assert.GreaterOrEqual(t, c.ValidateResults[1].Duration, 200*time.Millisecond)
+
+	c = controlCase(control("retry", "Retry", map[string]interface{}{"TimeoutMs": "250"}), expectWithin("fast", "5"))
+	e.executeSteps(c)
+	assert.Equal(t, []string{"retry passed", "fast passed"}, statuses(c))
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	variables := map[string]string{"OrdStatus": "8", "Price": "10.50", "Text": "filled"}
+	for condition, expected := range map[string]bool{
+		"OrdStatus == 8":      true,
+		"OrdStatus==08":       true,
+		"Price > 10.5":        false,
+		"Price >= 10.5":       true,
+		"Price < 9":           false,
+		"Text == 'filled'":    true,
+		"${Text} != rejected": true,
+	} {
+		holds, err := evaluateCondition(condition, variables)
+		assert.NoError(t, err, condition)
+		assert.Equal(t, expected, holds, condition)
+	}
+	_, err := evaluateCondition("OrdStatus ~ 8", variables)
+	assert.Error(t, err)
+	_, err = evaluateCondition("Missing == 8", variables)
+	assert.Error(t, err)
+}
+
+func TestStepPauseInBlock(t *testing.T) {
+	e := &CaseExecutor{}
+	step := &testcase.TestStep{SleepMs: "20"}
+	assert.Equal(t, time.Second, e.stepPause(step))
+	e.inBlock = 1
+	assert.Equal(t, 20*time.Millisecond, e.stepPause(step))
+	assert.Zero(t, e.stepPause(&testcase.TestStep{}))
+}
@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+// DifferentialExecutor runs the same cases against two gateways concurrently, each with its
+// own simulators, and compares the messages received by the compared simulators of one gateway
+// with those of the other.
+type DifferentialExecutor struct {
+	Config    config.GwAutoConfig
+	Baseline  *CaseExecutor
+	Candidate *CaseExecutor
+}
+
+// NewDifferentialExecutor creates the executors of both targets of the differential configuration
+func NewDifferentialExecutor(conf config.GwAutoConfig, cases []*testcase.TestCase) (*DifferentialExecutor, error) {
+	targets := conf.Differential.Targets
+	if len(targets) != 2 {
+		return nil, fmt.Errorf("differential testing needs 2 targets, got %d", len(targets))
+	}
+	compared := make(map[string]bool)
+	for _, name := range conf.Differential.Compare {
+		compared[name] = true
+	}
+	if len(compared) == 0 {
+		for _, s := range conf.Simulators {
+			if s.Type == "tgw" {
+				compared[s.Name] = true
+			}
+		}
+	}
+	executors := make([]*CaseExecutor, len(targets))
+	for i, target := range targets {
+		targetCases := make([]*testcase.TestCase, len(cases))
+		for j, c := range cases {
+			targetCases[j] = c.Clone()
+		}
+		executors[i] = NewCaseExecutor(conf.ForTarget(target), targetCases)
+		executors[i].compared = compared
+	}
+	return &DifferentialExecutor{Config: conf, Baseline: executors[0], Candidate: executors[1]}, nil
+}
+
+// Execute runs the cases against both targets and reports the divergences,
+// it returns whether the targets received the same messages and passed their other validations.
+func (d *DifferentialExecutor) Execute() bool {
+	var wg sync.WaitGroup
+	for _, e := range []*CaseExecutor{d.Baseline, d.Candidate} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.run()
+		}()
+	}
+	wg.Wait()
+	targets := d.Config.Differential.Targets
+	passed := true
+	d.Baseline.showSection(d.Baseline.Sections.SuiteSetup)
+	d.Candidate.showSection(d.Candidate.Sections.SuiteSetup)
+	for i, baseline := range d.Baseline.Cases {
+		candidate := d.Candidate.Cases[i]
+		for _, r := range append(baseline.ValidateResults, candidate.ValidateResults...) {
+			passed = passed && r.Passed
+		}
+		log.Infof("Show to differential result: %d, %s - %s\n", i, baseline.CaseID, baseline.CaseTitle)
+		for j := range baseline.Steps {
+			step := &baseline.Steps[j]
+			name, _ := parseTestTool(step.TestTool)
+			if step.ActionType != "Receive" || !d.Baseline.compared[name] {
+				continue
+			}
+			result := compareReceived(step.Actual(), candidate.Steps[j].Actual()).Ignore(d.Config.Differential.Ignore...)
+			if result.Equal {
+				log.Infof("Show to differential result: %d-%s:✅", j, step.StepID)
+				continue
+			}
+			passed = false
+			log.Errorf("Show to differential result: %d, %s❌", j, step.StepID)
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Path", targets[0].Name, targets[1].Name})
+			for _, diff := range result.Diffs {
+				table.Append([]string{
+					diff.Path,
+					fmt.Sprintf("%v", diff.Expect),
+					fmt.Sprintf("%v", diff.Actual),
+				})
+			}
+			table.Render()
+		}
+		d.Baseline.showResult(i, baseline)
+		d.Candidate.showResult(i, candidate)
+	}
+	d.Baseline.showSection(d.Baseline.Sections.SuiteTeardown)
+	d.Candidate.showSection(d.Candidate.Sections.SuiteTeardown)
+	return passed
+}
+
+// compareReceived compares the messages received by both targets, a missing message is a difference
+func compareReceived(baseline, candidate interface{}) validate.CompareResult {
+	if baseline == nil || candidate == nil {
+		if baseline == candidate {
+			return validate.CompareResult{Equal: true}
+		}
+		return validate.CompareResult{Diffs: []validate.Diff{{
+			Path:   "message",
+			Expect: received(baseline),
+			Actual: received(candidate),
+		}}}
+	}
+	return validate.CompareStruct(baseline, candidate)
+}
+
+func received(msg interface{}) string {
+	if msg == nil {
+		return "<none>"
+	}
+	return fmt.Sprintf("%T", msg)
+}
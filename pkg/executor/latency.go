@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	gt_codec "github.com/xinchentechnote/gt-auto/pkg/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+// defaultCorrelationField identifies the order a message belongs to when the configuration sets no field
+const defaultCorrelationField = "ClOrdID"
+
+// correlationField returns the field identifying the order a message belongs to
+func (e *CaseExecutor) correlationField() string {
+	if field := e.Config.Latency.CorrelationField; field != "" {
+		return field
+	}
+	return defaultCorrelationField
+}
+
+// frameTime returns when the frame of the step was written or read, zero when the simulator does not time frames
+func frameTime(simulator tcp.Simulator[codec.BinaryCodec], sent bool) time.Time {
+	timer, ok := simulator.(tcp.FrameTimer)
+	if !ok {
+		return time.Time{}
+	}
+	if sent {
+		return timer.LastSent()
+	}
+	return timer.LastReceived()
+}
+
+// measureLatency correlates a Receive step with the latest earlier Send step of another simulator,
+// the one that sent the same value of field, ClOrdID by default, when the message has one, and sets its hop latency.
+func measureLatency(c *testcase.TestCase, index int, name string, actual codec.BinaryCodec, field string) {
+	step := &c.Steps[index]
+	if step.FrameTime.IsZero() {
+		return
+	}
+	fields, err := gt_codec.MessageToMap(actual)
+	if err != nil {
+		return
+	}
+	id, hasID := testcase.LookupField(fields, field)
+	for i := index - 1; i >= 0; i-- {
+		sent := &c.Steps[i]
+		sender, _ := parseTestTool(sent.TestTool)
+		if sent.ActionType != "Send" || sender == name || sent.FrameTime.IsZero() {
+			continue
+		}
+		if hasID {
+			value, ok := testcase.LookupField(c.Substitute(sent.TestDatas), field)
+			if !ok || strings.TrimSpace(fmt.Sprint(value)) != strings.TrimSpace(fmt.Sprint(id)) {
+				continue
+			}
+		}
+		step.Hop = sender + "→" + name
+		step.Latency = step.FrameTime.Sub(sent.FrameTime)
+		log.Infof("Latency of %s %s: %s", step.StepID, step.Hop, step.Latency)
+		return
+	}
+}
+
+// expectLatency checks the latency of a Receive step: ReceiveStep, or the latest Receive step
+// with a latency before this one, must be within WithinMs.
+func expectLatency(index int, c *testcase.TestCase, step *testcase.TestStep) error {
+	within, ok := durationMs(step.TestDatas["WithinMs"])
+	if !ok {
+		return errors.New("no WithinMs")
+	}
+	target := fmt.Sprint(step.TestDatas["ReceiveStep"])
+	var measured *testcase.TestStep
+	for i := index - 1; i >= 0; i-- {
+		s := &c.Steps[i]
+		if s.StepID == target || (step.TestDatas["ReceiveStep"] == nil && s.Hop != "") {
+			measured = s
+			break
+		}
+	}
+	result := validate.CompareResult{Equal: true}
+	switch {
+	case measured == nil || measured.Hop == "":
+		result.Equal = false
+		result.Diffs = append(result.Diffs, validate.Diff{Path: "Latency", Expect: fmt.Sprintf("within %s", within), Actual: "not measured"})
+	case measured.Latency > within:
+		result.Equal = false
+		result.Diffs = append(result.Diffs, validate.Diff{Path: "Latency " + measured.Hop, Expect: fmt.Sprintf("within %s", within), Actual: measured.Latency.String()})
+	default:
+		log.Infof("%s %s: %s %s", step.ActionType, measured.StepID, measured.Hop, measured.Latency)
+	}
+	c.AddValidateResult(index, step.StepID, result)
+	return nil
+}
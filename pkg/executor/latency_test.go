@@ -0,0 +1,39 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/gt-auto/pkg/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+func TestMeasureLatencyCorrelationField(t *testing.T) {
+	c, err := codec.GetDefaultMessageCodecFactory().GetCodec(codec.JSONLines)
+	require.NoError(t, err)
+	received, err := c.JSONToStruct(map[string]interface{}{"MsgType": "ExecutionReport", "OrderID": "x1"})
+	require.NoError(t, err)
+	start := time.Now()
+	latencyCase := func() *testcase.TestCase {
+		return &testcase.TestCase{Steps: []testcase.TestStep{
+			{StepID: "cancel1", ActionType: "Send", TestTool: "oms", TestDatas: map[string]interface{}{"OrderID": "x1"}, FrameTime: start},
+			{StepID: "cancel2", ActionType: "Send", TestTool: "oms", TestDatas: map[string]interface{}{"OrderID": "x2"}, FrameTime: start.Add(time.Millisecond)},
+			{StepID: "recv", ActionType: "Receive", TestTool: "tgw", FrameTime: start.Add(3 * time.Millisecond)},
+		}}
+	}
+
+	// ClOrdID is not in the message, so the latest send is taken
+	e := &CaseExecutor{}
+	lc := latencyCase()
+	measureLatency(lc, 2, "tgw", received, e.correlationField())
+	assert.Equal(t, 2*time.Millisecond, lc.Steps[2].Latency)
+
+	e = &CaseExecutor{Config: config.GwAutoConfig{Latency: config.LatencyConfig{CorrelationField: "OrderID"}}}
+	lc = latencyCase()
+	measureLatency(lc, 2, "tgw", received, e.correlationField())
+	assert.Equal(t, "oms→tgw", lc.Steps[2].Hop)
+	assert.Equal(t, 3*time.Millisecond, lc.Steps[2].Latency)
+}
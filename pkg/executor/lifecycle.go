@@ -0,0 +1,145 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+// defaultExpectTimeout bounds ExpectConnect and ExpectDisconnect when TimeoutMs is not given
+const defaultExpectTimeout = 5 * time.Second
+
+// isLifecycleAction reports whether actionType controls or checks connections rather than messages
+func isLifecycleAction(actionType string) bool {
+	switch actionType {
+	case "Disconnect", "Reconnect", "StopListener", "StartListener", "ExpectConnect", "ExpectDisconnect",
+		"InjectFaults", "ClearFaults":
+		return true
+	}
+	return false
+}
+
+// executeLifecycleStep runs a connection lifecycle action.
+// InjectFaults replaces the faults of the simulator by those of the test data, ClearFaults removes them.
+// Disconnect, Reconnect, StopListener and StartListener mark the time the simulator was acted on,
+// ExpectConnect and ExpectDisconnect wait up to TimeoutMs for the event after that mark and
+// fail when it took longer than WithinMs, if given.
+func (e *CaseExecutor) executeLifecycleStep(index int, c *testcase.TestCase, step *testcase.TestStep,
+	name string, session string, simulator tcp.Simulator[codec.BinaryCodec]) error {
+	var err error
+	switch step.ActionType {
+	case "Disconnect":
+		if d, ok := simulator.(tcp.Disconnector); ok {
+			err = d.Disconnect(session)
+		} else {
+			err = errors.New("disconnect not supported")
+		}
+	case "Reconnect":
+		if r, ok := simulator.(tcp.Reconnector); ok {
+			err = r.Reconnect()
+		} else {
+			err = errors.New("reconnect not supported")
+		}
+	case "StopListener":
+		if l, ok := simulator.(tcp.ListenerController); ok {
+			err = l.StopListener()
+		} else {
+			err = errors.New("stop listener not supported")
+		}
+	case "StartListener":
+		if l, ok := simulator.(tcp.ListenerController); ok {
+			err = l.StartListener()
+		} else {
+			err = errors.New("start listener not supported")
+		}
+	case "InjectFaults", "ClearFaults":
+		return e.setFaults(step, simulator)
+	case "ExpectConnect":
+		return e.expectEvent(index, c, step, name, session, simulator, tcp.Connected)
+	case "ExpectDisconnect":
+		return e.expectEvent(index, c, step, name, session, simulator, tcp.Disconnected)
+	}
+	e.lifecycleMarks[name] = time.Now()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", step.ActionType, step.TestTool, err)
+	}
+	log.Infof("%s %s done", step.ActionType, step.TestTool)
+	return nil
+}
+
+func (e *CaseExecutor) setFaults(step *testcase.TestStep, simulator tcp.Simulator[codec.BinaryCodec]) error {
+	injectable, ok := simulator.(tcp.FaultInjectable)
+	if !ok {
+		return fmt.Errorf("%s %s failed: fault injection not supported", step.ActionType, step.TestTool)
+	}
+	var faults config.FaultConfig
+	if step.ActionType == "InjectFaults" {
+		var err error
+		if faults, err = config.ParseFaultConfig(step.TestDatas); err != nil {
+			return fmt.Errorf("%s %s failed: %w", step.ActionType, step.TestTool, err)
+		}
+	}
+	injectable.SetFaults(faults)
+	log.Infof("%s %s: %+v", step.ActionType, step.TestTool, faults)
+	return nil
+}
+
+func (e *CaseExecutor) expectEvent(index int, c *testcase.TestCase, step *testcase.TestStep,
+	name string, session string, simulator tcp.Simulator[codec.BinaryCodec], eventType tcp.ConnectionEventType) error {
+	source, ok := simulator.(tcp.EventSource)
+	if !ok {
+		return fmt.Errorf("%s %s failed: connection events not supported", step.ActionType, step.TestTool)
+	}
+	timeout := defaultExpectTimeout
+	if ms, ok := durationMs(step.TestDatas["TimeoutMs"]); ok {
+		timeout = ms
+	}
+	since := e.lifecycleMarks[name]
+	event, err := source.Events().Wait(eventType, session, since, timeout)
+	result := validate.CompareResult{Equal: true}
+	if err != nil {
+		result.Equal = false
+		result.Diffs = append(result.Diffs, validate.Diff{Path: string(eventType), Expect: fmt.Sprintf("within %s", timeout), Actual: err.Error()})
+	} else {
+		elapsed := event.Time.Sub(since)
+		if since.IsZero() {
+			elapsed = 0
+		}
+		log.Infof("%s %s: session %d %s after %s", step.ActionType, step.TestTool, event.Session.Index, event.Session.ID, elapsed)
+		if within, ok := durationMs(step.TestDatas["WithinMs"]); ok && elapsed > within {
+			result.Equal = false
+			result.Diffs = append(result.Diffs, validate.Diff{Path: string(eventType), Expect: fmt.Sprintf("within %s", within), Actual: fmt.Sprintf("after %s", elapsed)})
+		}
+	}
+	c.AddValidateResult(index, step.StepID, result)
+	return nil
+}
+
+// waitConnected waits up to timeout while the simulator is still connecting and returns its state
+func waitConnected(reporter tcp.StateReporter, timeout time.Duration) tcp.SimulatorState {
+	deadline := time.Now().Add(timeout)
+	for reporter.State() == tcp.StateConnecting && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	return reporter.State()
+}
+
+// durationMs parses a number of milliseconds from test data
+func durationMs(v interface{}) (time.Duration, bool) {
+	if v == nil {
+		return 0, false
+	}
+	ms, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(ms * float64(time.Millisecond)), true
+}
@@ -0,0 +1,172 @@
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	gt_codec "github.com/xinchentechnote/gt-auto/pkg/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+// LintIssue is a problem found in a step by Lint
+type LintIssue struct {
+	CaseID  string
+	StepID  string
+	Message string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("case %s step %s: %s", i.CaseID, i.StepID, i.Message)
+}
+
+// Lint checks the cases against the configuration without opening any connection:
+// every action is known and has valid test data, every test tool is a configured simulator,
+// and the message of every Send and Receive step can be built by the codec of its simulator,
+// without fields that are not part of the message. Values referencing variables are only known
+// at run time and are not checked.
+func Lint(conf config.GwAutoConfig, cases []*testcase.TestCase) []LintIssue {
+	l := &linter{conf: conf, codecs: make(map[string]gt_codec.MessageCodec), codecErrs: make(map[string]error)}
+	var issues []LintIssue
+	for _, c := range cases {
+		for i := range c.Steps {
+			for _, message := range l.lintStep(c, i) {
+				issues = append(issues, LintIssue{CaseID: c.CaseID, StepID: c.Steps[i].StepID, Message: message})
+			}
+		}
+	}
+	return issues
+}
+
+type linter struct {
+	conf      config.GwAutoConfig
+	codecs    map[string]gt_codec.MessageCodec
+	codecErrs map[string]error
+}
+
+// lintStep returns the problems of step index of c
+func (l *linter) lintStep(c *testcase.TestCase, index int) []string {
+	step := &c.Steps[index]
+	var problems []string
+	number := func(field string, required bool) {
+		v, ok := step.TestDatas[field]
+		if !ok {
+			if required {
+				problems = append(problems, "no "+field)
+			}
+			return
+		}
+		if _, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(v)), 64); err != nil {
+			problems = append(problems, fmt.Sprintf("%s is not a number: %v", field, v))
+		}
+	}
+	switch {
+	case isControlAction(step.ActionType):
+		number("Steps", false)
+		switch step.ActionType {
+		case "Repeat":
+			number("Times", true)
+		case "If":
+			if !conditionPattern.MatchString(fmt.Sprint(step.TestDatas["Condition"])) {
+				problems = append(problems, fmt.Sprintf("invalid condition %q", step.TestDatas["Condition"]))
+			}
+		case "Retry":
+			number("TimeoutMs", false)
+		}
+		return problems
+	case step.ActionType == "ExpectLatency":
+		number("WithinMs", true)
+		if target, ok := step.TestDatas["ReceiveStep"]; ok && !hasStepBefore(c, index, fmt.Sprint(target)) {
+			problems = append(problems, fmt.Sprintf("no ReceiveStep %v before the step", target))
+		}
+		return problems
+	case step.ActionType == "Send", step.ActionType == "Receive", isLifecycleAction(step.ActionType):
+	default:
+		return append(problems, fmt.Sprintf("unknown action type: %s", step.ActionType))
+	}
+
+	name, _ := parseTestTool(step.TestTool)
+	simulator, ok := l.conf.SimulatorMap[name]
+	if !ok {
+		return append(problems, fmt.Sprintf("unknown test tool: %q", step.TestTool))
+	}
+	switch step.ActionType {
+	case "InjectFaults":
+		if _, err := config.ParseFaultConfig(step.TestDatas); err != nil {
+			problems = append(problems, err.Error())
+		}
+	case "ExpectConnect", "ExpectDisconnect":
+		number("TimeoutMs", false)
+		number("WithinMs", false)
+	case "Send", "Receive":
+		if strings.TrimSpace(step.MsgType) == "" {
+			return append(problems, "no msg_type")
+		}
+		messageCodec, err := l.codec(simulator)
+		if err != nil {
+			return append(problems, fmt.Sprintf("simulator %s: %s", name, err))
+		}
+		problems = append(problems, lintMessage(messageCodec, step)...)
+	}
+	return problems
+}
+
+// codec returns the codec of the simulator, created once
+func (l *linter) codec(simulator config.SimulatorConfig) (gt_codec.MessageCodec, error) {
+	if c, ok := l.codecs[simulator.Name]; ok {
+		return c, l.codecErrs[simulator.Name]
+	}
+	var c gt_codec.MessageCodec
+	var err error
+	if simulator.Communication == "http" {
+		c = &gt_codec.HTTPMessageCodec{}
+	} else {
+		_, c, err = tcp.CreateFramerAndCodec(simulator)
+	}
+	l.codecs[simulator.Name], l.codecErrs[simulator.Name] = c, err
+	return c, err
+}
+
+// lintMessage builds the message of a Send or Receive step, leaving out the values only known at run time
+func lintMessage(messageCodec gt_codec.MessageCodec, step *testcase.TestStep) (problems []string) {
+	data := step.TestDatas
+	if step.ActionType == "Receive" {
+		data, _, _ = testcase.Expectations(data)
+	}
+	fields := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		if s, ok := v.(string); ok && strings.Contains(s, "${") {
+			continue
+		}
+		fields[k] = v
+	}
+	fields["MsgType"] = step.MsgType
+	defer func() {
+		if r := recover(); r != nil {
+			problems = append(problems, fmt.Sprintf("cannot build message %s: %v", step.MsgType, r))
+		}
+	}()
+	message, err := messageCodec.JSONToStruct(fields)
+	if err != nil {
+		return []string{fmt.Sprintf("cannot build message %s: %s", step.MsgType, err)}
+	}
+	unknown, err := gt_codec.UnknownFields(fields, message)
+	if err != nil {
+		return []string{fmt.Sprintf("cannot convert message %s: %s", step.MsgType, err)}
+	}
+	for _, field := range unknown {
+		problems = append(problems, fmt.Sprintf("unknown field %s in message %s", field, step.MsgType))
+	}
+	return problems
+}
+
+func hasStepBefore(c *testcase.TestCase, index int, stepID string) bool {
+	for i := 0; i < index; i++ {
+		if c.Steps[i].StepID == stepID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+func TestLint(t *testing.T) {
+	conf := config.GwAutoConfig{Simulators: []config.SimulatorConfig{
+		{Name: "json_oms_1", Type: "oms", Protocol: "json-lines"},
+		{Name: "bad_tgw_1", Type: "tgw", Protocol: "no-such-protocol"},
+	}}
+	conf.InitConfigMap()
+	step := func(id, action, tool, msgType string, data map[string]interface{}) testcase.TestStep {
+		return testcase.TestStep{StepID: id, ActionType: action, TestTool: tool, MsgType: msgType, TestDatas: data}
+	}
+	c := &testcase.TestCase{CaseID: "lint_001", Steps: []testcase.TestStep{
+		step("send", "Send", "json_oms_1@1", "NewOrder", map[string]interface{}{"ClOrdID": "${Id}"}),
+		step("no_type", "Send", "json_oms_1", "", map[string]interface{}{}),
+		step("no_tool", "Receive", "missing_1", "Ack", map[string]interface{}{}),
+		step("bad_codec", "Receive", "bad_tgw_1", "Ack", map[string]interface{}{}),
+		step("shout", "Shout", "json_oms_1", "", nil),
+		step("faults", "InjectFaults", "json_oms_1", "", map[string]interface{}{"latency_ms": "soon"}),
+		step("repeat", "Repeat", "", "", map[string]interface{}{"Steps": "2"}),
+		step("if", "If", "", "", map[string]interface{}{"Condition": "OrdStatus ~ 8"}),
+		step("latency", "ExpectLatency", "", "", map[string]interface{}{"ReceiveStep": "later", "WithinMs": "5"}),
+		step("disconnect", "Disconnect", "json_oms_1", "", map[string]interface{}{}),
+	}}
+
+	issues := Lint(conf, []*testcase.TestCase{c})
+	steps := make(map[string]int)
+	for _, issue := range issues {
+		steps[issue.StepID]++
+	}
+	assert.Equal(t, map[string]int{"no_type": 1, "no_tool": 1, "bad_codec": 1, "shout": 1, "faults": 1,
+		"repeat": 1, "if": 1, "latency": 1}, steps, "%v", issues)
+	assert.Equal(t, "case lint_001 step repeat: no Times", issues[5].String())
+}
@@ -0,0 +1,66 @@
+package executor
+
+import (
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+// ExecuteParallel shards the cases across workers, each running its cases in sequence with its own
+// simulators, see config.GwAutoConfig.ForWorker, and shows the results in the order of the cases.
+// Every worker runs the setup and teardown sections around its own cases, a step aborting the suite
+// skips the cases not started by any worker.
+func ExecuteParallel(conf config.GwAutoConfig, cases []*testcase.TestCase, workers int, failFast bool) error {
+	executors, cases, err := runWorkers(conf, cases, workers, failFast)
+	if err != nil {
+		return err
+	}
+	for _, e := range executors {
+		e.showSection(e.Sections.SuiteSetup)
+	}
+	for i, c := range cases {
+		executors[i%len(executors)].showResult(i, c)
+	}
+	for _, e := range executors {
+		e.showSection(e.Sections.SuiteTeardown)
+	}
+	return nil
+}
+
+// runWorkers runs the cases on their workers and returns the executors of the workers with the
+// cases without sections, case i having run on executor i%workers
+func runWorkers(conf config.GwAutoConfig, cases []*testcase.TestCase, workers int, failFast bool) ([]*CaseExecutor, []*testcase.TestCase, error) {
+	sections, cases := testcase.SplitSections(cases)
+	workers = max(1, min(workers, len(cases)))
+	shards := make([][]*testcase.TestCase, workers)
+	for i, c := range cases {
+		shards[i%workers] = append(shards[i%workers], c)
+	}
+	configs := make([]config.GwAutoConfig, workers)
+	for i := range configs {
+		var err error
+		if configs[i], err = conf.ForWorker(i); err != nil {
+			return nil, nil, err
+		}
+	}
+	executors := make([]*CaseExecutor, workers)
+	suiteAborted := new(atomic.Bool)
+	var wg sync.WaitGroup
+	for i := range executors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			executors[i] = NewCaseExecutor(configs[i], shards[i])
+			executors[i].Sections = sections.Clone()
+			executors[i].FailFast = failFast
+			executors[i].suiteAborted = suiteAborted
+			log.Infof("Worker %d runs %d cases", i, len(shards[i]))
+			executors[i].run()
+		}()
+	}
+	wg.Wait()
+	return executors, cases, nil
+}
@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+// workerConfig returns an oms talking to a tgw directly, on two free ports for workers 0 and 1
+func workerConfig(t *testing.T) config.GwAutoConfig {
+	var ports []int
+	for i := 0; i < 2; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		ports = append(ports, listener.Addr().(*net.TCPAddr).Port)
+	}
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(ports[0]))
+	return config.GwAutoConfig{
+		Simulators: []config.SimulatorConfig{
+			{Name: "tgw", Type: "tgw", Protocol: "json-lines", ListenAddress: address},
+			{Name: "oms", Type: "oms", Protocol: "json-lines", ServerAddress: address},
+		},
+		Parallel: config.ParallelConfig{PortStep: ports[1] - ports[0]},
+	}
+}
+
+// orderCases returns n cases sending an order from the oms and checking it at the tgw
+func orderCases(n int) []*testcase.TestCase {
+	var cases []*testcase.TestCase
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("order_%03d", i)
+		data := map[string]interface{}{"ClOrdID": id}
+		cases = append(cases, &testcase.TestCase{CaseID: id, Steps: []testcase.TestStep{
+			{StepID: "send", ActionType: "Send", TestTool: "oms", MsgType: "NewOrder", TestDatas: data},
+			{StepID: "recv", ActionType: "Receive", TestTool: "tgw", MsgType: "NewOrder", VerifyRequired: true, TestDatas: data},
+		}})
+	}
+	return cases
+}
+
+func closeWorkers(t *testing.T, executors []*CaseExecutor) {
+	t.Cleanup(func() {
+		for _, e := range executors {
+			for _, sim := range e.simulatorMap {
+				sim.Close()
+			}
+		}
+	})
+}
+
+func TestExecuteParallel(t *testing.T) {
+	t.Parallel()
+	executors, cases, err := runWorkers(workerConfig(t), orderCases(4), 2, false)
+	require.NoError(t, err)
+	closeWorkers(t, executors)
+	require.Len(t, executors, 2)
+
+	var addresses []string
+	for _, e := range executors {
+		require.Len(t, e.Cases, 2)
+		addresses = append(addresses, e.simulatorMap["tgw"].(tcp.AddrReporter).Addr())
+	}
+	assert.NotEqual(t, addresses[0], addresses[1])
+	assert.Equal(t, executors[0].Config.SimulatorMap["tgw"].ListenAddress, addresses[0])
+	assert.Equal(t, executors[1].Config.SimulatorMap["tgw"].ListenAddress, addresses[1])
+
+	for i, c := range cases {
+		assert.Same(t, c, executors[i%2].Cases[i/2])
+		// one result per step, each order was received by the tgw of its own worker
+		assert.Equal(t, []string{"send passed", "recv passed"}, results(c), c.CaseID)
+	}
+}
+
+func TestExecuteParallelFailFast(t *testing.T) {
+	t.Parallel()
+	cases := orderCases(6)
+	cases[0].Steps[1].TestDatas = map[string]interface{}{"ClOrdID": "another order"}
+	executors, _, err := runWorkers(workerConfig(t), cases, 2, true)
+	require.NoError(t, err)
+	closeWorkers(t, executors)
+
+	assert.Equal(t, []string{"send passed", "recv failed"}, results(cases[0]))
+	assert.Equal(t, []string{"send passed", "recv passed"}, results(cases[1]))
+	// the failure on the first worker stops the second one before its last case
+	for _, c := range []*testcase.TestCase{cases[2], cases[4], cases[5]} {
+		assert.Equal(t, []string{"send skipped", "recv skipped"}, results(c), c.CaseID)
+	}
+}
+
+// results returns the step and status of every result of the case
+func results(c *testcase.TestCase) []string {
+	var results []string
+	for _, r := range c.ValidateResults {
+		results = append(results, r.StepID+" "+r.Status)
+	}
+	return results
+}
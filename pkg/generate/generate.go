@@ -0,0 +1,181 @@
+package generate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+// Options controls how captured messages are turned into test cases
+// Name, prefix of the case IDs, step IDs and data sheets
+// Tool, the simulator playing Side in the generated cases
+// Side, capture.FromClient or capture.FromServer, messages from Side become Send steps
+// and messages from the other side Receive steps
+// Source, only the records of this proxy or simulator are used, all of them when empty
+// Volatile, more fields whose received values are not compared
+type Options struct {
+	Name     string
+	Tool     string
+	Side     string
+	Source   string
+	Volatile []string
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// FromCapture generates one test case per captured session.
+// Received timestamps, fields whose name ends with Time, and the fields of Options.Volatile are wildcards.
+// Received identifiers, fields whose name ends with ID, Id or No, are captured as variables
+// unless the tool sent the value first, and sent values equal to a captured one refer to its variable.
+func FromCapture(records []capture.Record, opts Options) ([]*testcase.TestCase, error) {
+	if opts.Side != capture.FromClient && opts.Side != capture.FromServer {
+		return nil, fmt.Errorf("unknown side: %q, expected %s or %s", opts.Side, capture.FromClient, capture.FromServer)
+	}
+	volatile := make(map[string]bool, len(opts.Volatile))
+	for _, f := range opts.Volatile {
+		volatile[f] = true
+	}
+	type sessionKey struct {
+		source  string
+		session int
+	}
+	var keys []sessionKey
+	sessions := make(map[sessionKey][]capture.Record)
+	for _, r := range records {
+		if opts.Source != "" && r.Source != opts.Source {
+			continue
+		}
+		key := sessionKey{r.Source, r.Session}
+		if _, ok := sessions[key]; !ok {
+			keys = append(keys, key)
+		}
+		sessions[key] = append(sessions[key], r)
+	}
+	var cases []*testcase.TestCase
+	for i, key := range keys {
+		g := &caseGenerator{
+			opts:      opts,
+			volatile:  volatile,
+			sent:      make(map[string]bool),
+			variables: make(map[string]string),
+			counters:  make(map[string]int),
+		}
+		c := &testcase.TestCase{
+			CaseID:    fmt.Sprintf("%s_%03d", opts.Name, i+1),
+			CaseTitle: fmt.Sprintf("%s session %d", key.source, key.session),
+		}
+		for _, r := range sessions[key] {
+			if r.Message == nil {
+				log.Warnf("Skipping undecoded message of %s session %d at %s: %s", r.Source, r.Session, r.Time, r.Error)
+				continue
+			}
+			c.Steps = append(c.Steps, g.step(fmt.Sprintf("%s_%03d", c.CaseID, len(c.Steps)+1), r))
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// caseGenerator tracks the values exchanged within one case
+type caseGenerator struct {
+	opts     Options
+	volatile map[string]bool
+	// sent holds the values sent by the tool
+	sent map[string]bool
+	// variables maps a received identifier value to its variable
+	variables map[string]string
+	counters  map[string]int
+}
+
+func (g *caseGenerator) step(stepID string, r capture.Record) testcase.TestStep {
+	msgType := r.MsgType
+	if msgType == "" {
+		msgType = fmt.Sprint(r.Message["MsgType"])
+	}
+	step := testcase.TestStep{
+		StepID:    stepID,
+		SleepMs:   "0",
+		TestTool:  g.opts.Tool,
+		MsgType:   msgType,
+		TestData:  g.opts.Name + "_" + unsafeChars.ReplaceAllString(msgType, "_"),
+		TestDatas: make(map[string]interface{}, len(r.Message)),
+	}
+	send := r.From == g.opts.Side
+	if send {
+		step.ActionType = "Send"
+		step.StepDesc = fmt.Sprintf("%s sends %s", g.opts.Tool, msgType)
+	} else {
+		step.ActionType = "Receive"
+		step.StepDesc = fmt.Sprintf("%s receives %s", g.opts.Tool, msgType)
+		step.VerifyRequired = true
+	}
+	for _, field := range sortedKeys(r.Message) {
+		if field == "MsgType" {
+			continue
+		}
+		value := r.Message[field]
+		if send {
+			step.TestDatas[field] = g.sendValue(value)
+		} else {
+			step.TestDatas[field] = g.receiveValue(field, value)
+		}
+	}
+	return step
+}
+
+func (g *caseGenerator) sendValue(value interface{}) interface{} {
+	text, ok := scalarText(value)
+	if !ok {
+		return value
+	}
+	g.sent[text] = true
+	if name, ok := g.variables[text]; ok {
+		return "${" + name + "}"
+	}
+	return value
+}
+
+func (g *caseGenerator) receiveValue(field string, value interface{}) interface{} {
+	if g.volatile[field] || validate.IsTimeField(field) {
+		return testcase.Wildcard
+	}
+	text, ok := scalarText(value)
+	if !ok || text == "" || g.sent[text] || !isIdentifier(field) {
+		return value
+	}
+	if name, ok := g.variables[text]; ok {
+		return "${" + name + "}"
+	}
+	g.counters[field]++
+	name := fmt.Sprintf("%s_%d", field, g.counters[field])
+	g.variables[text] = name
+	return "${" + name + "}"
+}
+
+func isIdentifier(field string) bool {
+	return strings.HasSuffix(field, "ID") || strings.HasSuffix(field, "Id") || strings.HasSuffix(field, "No")
+}
+
+// scalarText returns the text of a scalar value, nested values have none
+func scalarText(value interface{}) (string, bool) {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
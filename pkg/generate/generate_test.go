@@ -0,0 +1,68 @@
+package generate
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	"github.com/xinchentechnote/gt-auto/pkg/testcase"
+)
+
+func TestFromCapture(t *testing.T) {
+	records, err := capture.ReadFile("testdata/capture.jsonl")
+	assert.NoError(t, err)
+	cases, err := FromCapture(records, Options{Name: "rec", Tool: "oms", Side: capture.FromClient})
+	assert.NoError(t, err)
+	assert.Len(t, cases, 2)
+
+	steps := cases[0].Steps
+	assert.Len(t, steps, 3)
+	assert.Equal(t, "Send", steps[0].ActionType)
+	assert.Equal(t, "rec_NewOrder", steps[0].TestData)
+	assert.NotContains(t, steps[0].TestDatas, "MsgType")
+
+	assert.Equal(t, "Receive", steps[1].ActionType)
+	assert.True(t, steps[1].VerifyRequired)
+	assert.Equal(t, "C1", steps[1].TestDatas["ClOrdID"])
+	assert.Equal(t, testcase.Wildcard, steps[1].TestDatas["TransactTime"])
+	assert.Equal(t, "${OrderID_1}", steps[1].TestDatas["OrderID"])
+
+	assert.Equal(t, "${OrderID_1}", steps[2].TestDatas["OrderID"])
+	assert.Len(t, cases[1].Steps, 1)
+}
+
+func TestFromCaptureServerSide(t *testing.T) {
+	records, err := capture.ReadFile("testdata/capture.jsonl")
+	assert.NoError(t, err)
+	cases, err := FromCapture(records, Options{Name: "rec", Tool: "tgw", Side: capture.FromServer, Volatile: []string{"OrderQty"}})
+	assert.NoError(t, err)
+	steps := cases[0].Steps
+	assert.Equal(t, "Receive", steps[0].ActionType)
+	assert.Equal(t, testcase.Wildcard, steps[0].TestDatas["OrderQty"])
+	assert.Equal(t, "${ClOrdID_1}", steps[0].TestDatas["ClOrdID"])
+	assert.Equal(t, "Send", steps[1].ActionType)
+	assert.Equal(t, "${ClOrdID_1}", steps[1].TestDatas["ClOrdID"])
+
+	_, err = FromCapture(records, Options{Side: "both"})
+	assert.Error(t, err)
+}
+
+func TestWriteGeneratedCases(t *testing.T) {
+	records, err := capture.ReadFile("testdata/capture.jsonl")
+	assert.NoError(t, err)
+	cases, err := FromCapture(records, Options{Name: "rec", Tool: "oms", Side: capture.FromClient})
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "rec_test_case.csv")
+	assert.NoError(t, testcase.WriteCSVCases(path, cases))
+
+	loaded, err := testcase.LoadTestCases(path)
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 2)
+	assert.Equal(t, "rec_001", loaded[0].CaseID)
+	assert.Len(t, loaded[0].Steps, 3)
+	assert.Equal(t, "ExecutionReport", loaded[0].Steps[1].MsgType)
+	assert.Equal(t, "${OrderID_1}", loaded[0].Steps[1].TestDatas["OrderID"])
+	assert.Equal(t, "*", loaded[0].Steps[1].TestDatas["TransactTime"])
+	assert.Equal(t, "100", loaded[0].Steps[0].TestDatas["OrderQty"])
+}
@@ -0,0 +1,128 @@
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	"github.com/xinchentechnote/gt-auto/pkg/codec"
+)
+
+// Options of Import
+// Port, the server port of the connections to import
+// Source, the source of the records, the file name when empty
+type Options struct {
+	Port   uint16
+	Source string
+	Framer codec.Framer
+	Codec  codec.MessageCodec
+}
+
+// Import reads a pcap or pcapng file and decodes the messages of the connections to Options.Port
+// into a timeline of capture records, in the order they were captured.
+// The packets read before a truncated end of file are still imported.
+func Import(path string, opts Options) ([]capture.Record, error) {
+	packets, err := ReadFile(path)
+	if err != nil {
+		if len(packets) == 0 {
+			return nil, err
+		}
+		log.Warnf("Importing the %d packets read: %s", len(packets), err)
+	}
+	if opts.Source == "" {
+		opts.Source = path
+	}
+	return Decode(Reassemble(packets, opts.Port), opts), nil
+}
+
+// Decode frames and decodes both directions of the streams, merging them by capture time.
+// A frame is timed by the packet that completed it.
+func Decode(streams []*Stream, opts Options) []capture.Record {
+	var records []capture.Record
+	for _, stream := range streams {
+		if stream.FromClient.Gaps > 0 || stream.FromServer.Gaps > 0 {
+			log.Warnf("Session %d %s -> %s misses data: %d client and %d server gaps", stream.Session,
+				stream.Client, stream.Server, stream.FromClient.Gaps, stream.FromServer.Gaps)
+		}
+		records = decodeFlow(records, stream.Session, capture.FromClient, &stream.FromClient, opts)
+		records = decodeFlow(records, stream.Session, capture.FromServer, &stream.FromServer, opts)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records
+}
+
+func decodeFlow(records []capture.Record, session int, from string, flow *Flow, opts Options) []capture.Record {
+	conn := &flowConn{data: flow.Data}
+	for conn.offset < len(conn.data) {
+		start := conn.offset
+		data, err := opts.Framer.ReadFrame(conn)
+		var checksumErr *codec.ChecksumError
+		if err != nil && !errors.As(err, &checksumErr) {
+			// the rest of the flow cannot be framed, it is kept as one undecoded record
+			record := capture.Record{
+				Protocol: opts.Codec.ProtoName(),
+				Raw:      flow.Data[start:],
+				Error:    fmt.Sprintf("failed to frame %d bytes: %s", len(flow.Data)-start, err),
+			}
+			return append(records, withOrigin(record, opts.Source, session, from, flow.TimeAt(len(flow.Data)-1)))
+		}
+		var fault error
+		if checksumErr != nil {
+			fault = err
+		}
+		record, _ := capture.Decode(opts.Codec, data, fault)
+		records = append(records, withOrigin(record, opts.Source, session, from, flow.TimeAt(conn.offset-1)))
+	}
+	return records
+}
+
+func withOrigin(record capture.Record, source string, session int, from string, t time.Time) capture.Record {
+	record.Time = t
+	record.Source = source
+	record.Session = session
+	record.From = from
+	return record
+}
+
+// flowConn lets a Framer read the data of a flow
+type flowConn struct {
+	data   []byte
+	offset int
+}
+
+func (c *flowConn) Read(b []byte) (int, error) {
+	if c.offset >= len(c.data) {
+		return 0, io.EOF
+	}
+	n := copy(b, c.data[c.offset:])
+	c.offset += n
+	return n, nil
+}
+
+// ReadByte lets line framers read the flow without buffering past a frame, the offset
+// then marks where each frame ends
+func (c *flowConn) ReadByte() (byte, error) {
+	if c.offset >= len(c.data) {
+		return 0, io.EOF
+	}
+	c.offset++
+	return c.data[c.offset-1], nil
+}
+
+func (c *flowConn) Write(b []byte) (int, error) {
+	return 0, errors.New("captured flows are read only")
+}
+
+func (c *flowConn) Close() error                       { return nil }
+func (c *flowConn) LocalAddr() net.Addr                { return nil }
+func (c *flowConn) RemoteAddr() net.Addr               { return nil }
+func (c *flowConn) SetDeadline(t time.Time) error      { return nil }
+func (c *flowConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *flowConn) SetWriteDeadline(t time.Time) error { return nil }
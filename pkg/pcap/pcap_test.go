@@ -0,0 +1,236 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	"github.com/xinchentechnote/gt-auto/pkg/codec"
+)
+
+var (
+	client = netip.MustParseAddrPort("10.0.0.1:50000")
+	server = netip.MustParseAddrPort("10.0.0.2:9001")
+	start  = time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC)
+)
+
+// ethernetFrame builds an ethernet frame carrying a TCP segment over IPv4, padded like a short frame on the wire
+func ethernetFrame(src, dst netip.AddrPort, seq uint32, flags uint8, payload string) []byte {
+	tcp := make([]byte, 20, 20+len(payload))
+	binary.BigEndian.PutUint16(tcp, src.Port())
+	binary.BigEndian.PutUint16(tcp[2:], dst.Port())
+	binary.BigEndian.PutUint32(tcp[4:], seq)
+	tcp[12] = 5 << 4
+	tcp[13] = flags
+	tcp = append(tcp, payload...)
+	ip := make([]byte, 20, 20+len(tcp))
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], uint16(20+len(tcp)))
+	ip[8] = 64
+	ip[9] = ipProtocolTCP
+	copy(ip[12:], src.Addr().AsSlice())
+	copy(ip[16:], dst.Addr().AsSlice())
+	ip = append(ip, tcp...)
+	frame := make([]byte, ethernetHeaderLen, ethernetHeaderLen+len(ip)+6)
+	binary.BigEndian.PutUint16(frame[12:], etherTypeIPv4)
+	frame = append(frame, ip...)
+	for len(frame) < 60 {
+		frame = append(frame, 0)
+	}
+	return frame
+}
+
+func writePcap(packets []Packet) []byte {
+	var buf bytes.Buffer
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header, pcapMagicMicros)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], 65535)
+	binary.LittleEndian.PutUint32(header[20:], LinkTypeEthernet)
+	buf.Write(header)
+	for _, p := range packets {
+		record := make([]byte, 16)
+		binary.LittleEndian.PutUint32(record, uint32(p.Time.Unix()))
+		binary.LittleEndian.PutUint32(record[4:], uint32(p.Time.Nanosecond()/1000))
+		binary.LittleEndian.PutUint32(record[8:], uint32(len(p.Data)))
+		binary.LittleEndian.PutUint32(record[12:], uint32(len(p.Data)))
+		buf.Write(record)
+		buf.Write(p.Data)
+	}
+	return buf.Bytes()
+}
+
+// appendOrder is a byte order writing to byte slices
+type appendOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+func pcapngBlock(order appendOrder, blockType uint32, body []byte) []byte {
+	for len(body)%4 != 0 {
+		body = append(body, 0)
+	}
+	block := make([]byte, 8, 12+len(body))
+	order.PutUint32(block, blockType)
+	order.PutUint32(block[4:], uint32(12+len(body)))
+	block = append(block, body...)
+	return order.AppendUint32(block, uint32(12+len(body)))
+}
+
+// writePcapng writes nanosecond timestamps, set by the if_tsresol option
+func writePcapng(order appendOrder, packets []Packet) []byte {
+	var buf bytes.Buffer
+	shb := order.AppendUint32(nil, pcapngByteOrderMagic)
+	shb = order.AppendUint16(shb, 1)
+	shb = order.AppendUint16(shb, 0)
+	shb = append(shb, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	buf.Write(pcapngBlock(order, pcapngSectionHeader, shb))
+	idb := order.AppendUint16(nil, LinkTypeEthernet)
+	idb = order.AppendUint16(idb, 0)
+	idb = order.AppendUint32(idb, 65535)
+	idb = order.AppendUint16(idb, pcapngOptionTsResol)
+	idb = order.AppendUint16(idb, 1)
+	idb = append(idb, 9, 0, 0, 0)
+	idb = order.AppendUint32(idb, pcapngOptionEnd)
+	buf.Write(pcapngBlock(order, pcapngInterface, idb))
+	for _, p := range packets {
+		ts := uint64(p.Time.UnixNano())
+		epb := order.AppendUint32(nil, 0)
+		epb = order.AppendUint32(epb, uint32(ts>>32))
+		epb = order.AppendUint32(epb, uint32(ts))
+		epb = order.AppendUint32(epb, uint32(len(p.Data)))
+		epb = order.AppendUint32(epb, uint32(len(p.Data)))
+		epb = append(epb, p.Data...)
+		buf.Write(pcapngBlock(order, pcapngEnhancedPacket, epb))
+	}
+	return buf.Bytes()
+}
+
+// conversation is a JSON lines exchange with a retransmission, an out of order segment
+// and a message split over two segments
+func conversation() []Packet {
+	const ack = 0x10
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	newOrder := `{"MsgType":"NewOrder","ClOrdID":"c1"}` + "\n"
+	report := `{"MsgType":"ExecutionReport","ClOrdID":"c1","OrderID":"o1"}` + "\n"
+	cancel := `{"MsgType":"CancelOrder","ClOrdID":"c2"}` + "\n"
+	frames := []struct {
+		ms      int
+		src     netip.AddrPort
+		dst     netip.AddrPort
+		seq     uint32
+		flags   uint8
+		payload string
+	}{
+		{0, client, server, 1000, tcpFlagSyn, ""},
+		{1, server, client, 5000, tcpFlagSyn | ack, ""},
+		{2, client, server, 1001, ack, newOrder},
+		{3, client, server, 1001, ack, newOrder},
+		{10, server, client, 5001, ack, report[:20]},
+		{12, server, client, 5021, ack, report[20:]},
+		{20, client, server, 1001 + uint32(len(newOrder)) + 10, ack, cancel[10:]},
+		{21, client, server, 1001 + uint32(len(newOrder)), ack, cancel[:10]},
+		{30, client, server, 1001 + uint32(len(newOrder)+len(cancel)), ack | tcpFlagFin, ""},
+	}
+	packets := make([]Packet, 0, len(frames)+1)
+	for _, f := range frames {
+		packets = append(packets, Packet{Time: at(f.ms), LinkType: LinkTypeEthernet, Data: ethernetFrame(f.src, f.dst, f.seq, f.flags, f.payload)})
+	}
+	// traffic on another port is ignored
+	other := netip.MustParseAddrPort("10.0.0.3:80")
+	return append(packets, Packet{Time: at(31), LinkType: LinkTypeEthernet, Data: ethernetFrame(client, other, 1, ack, "GET /")})
+}
+
+func TestReadPcapAndPcapng(t *testing.T) {
+	packets := conversation()
+	for name, data := range map[string][]byte{
+		"pcap":           writePcap(packets),
+		"pcapng":         writePcapng(binary.LittleEndian, packets),
+		"pcapng big end": writePcapng(binary.BigEndian, packets),
+	} {
+		read, err := Read(bytes.NewReader(data))
+		assert.NoError(t, err, name)
+		assert.Len(t, read, len(packets), name)
+		for i := range packets {
+			assert.True(t, packets[i].Time.Equal(read[i].Time), name)
+			assert.Equal(t, packets[i].Data, read[i].Data, name)
+			assert.Equal(t, uint32(LinkTypeEthernet), read[i].LinkType, name)
+		}
+	}
+	_, err := Read(bytes.NewReader([]byte("not a capture file at all")))
+	assert.Error(t, err)
+}
+
+func TestReassemble(t *testing.T) {
+	streams := Reassemble(conversation(), server.Port())
+	assert.Len(t, streams, 1)
+	stream := streams[0]
+	assert.Equal(t, 1, stream.Session)
+	assert.Equal(t, client, stream.Client)
+	assert.Equal(t, server, stream.Server)
+	assert.Equal(t, `{"MsgType":"NewOrder","ClOrdID":"c1"}`+"\n"+`{"MsgType":"CancelOrder","ClOrdID":"c2"}`+"\n", string(stream.FromClient.Data))
+	assert.Equal(t, `{"MsgType":"ExecutionReport","ClOrdID":"c1","OrderID":"o1"}`+"\n", string(stream.FromServer.Data))
+	assert.Zero(t, stream.FromClient.Gaps)
+	assert.Equal(t, start.Add(12*time.Millisecond), stream.FromServer.TimeAt(len(stream.FromServer.Data)-1))
+}
+
+func TestReassembleGap(t *testing.T) {
+	packets := []Packet{
+		{Time: start, LinkType: LinkTypeEthernet, Data: ethernetFrame(client, server, 1, 0, "abc")},
+		{Time: start, LinkType: LinkTypeEthernet, Data: ethernetFrame(client, server, 10, 0, "xyz")},
+	}
+	streams := Reassemble(packets, server.Port())
+	assert.Equal(t, "abcxyz", string(streams[0].FromClient.Data))
+	assert.Equal(t, 1, streams[0].FromClient.Gaps)
+}
+
+func TestImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "incident.pcapng")
+	assert.NoError(t, os.WriteFile(path, writePcapng(binary.LittleEndian, conversation()), 0o644))
+	factory := codec.GetDefaultMessageCodecFactory()
+	framer, _ := factory.GetFramer(codec.JSONLines)
+	c, _ := factory.GetCodec(codec.JSONLines)
+
+	records, err := Import(path, Options{Port: server.Port(), Source: "incident", Framer: framer, Codec: c})
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	expected := []struct {
+		from    string
+		msgType string
+		ms      int
+	}{
+		{capture.FromClient, "NewOrder", 2},
+		{capture.FromServer, "ExecutionReport", 12},
+		{capture.FromClient, "CancelOrder", 21},
+	}
+	for i, e := range expected {
+		assert.Equal(t, "incident", records[i].Source)
+		assert.Equal(t, 1, records[i].Session)
+		assert.Equal(t, e.from, records[i].From)
+		assert.Equal(t, e.msgType, records[i].MsgType)
+		assert.True(t, start.Add(time.Duration(e.ms)*time.Millisecond).Equal(records[i].Time), e.msgType)
+		assert.Empty(t, records[i].Error)
+	}
+	assert.Equal(t, "o1", records[1].Message["OrderID"])
+}
+
+func TestImportTruncatedFrame(t *testing.T) {
+	packets := []Packet{
+		{Time: start, LinkType: LinkTypeEthernet, Data: ethernetFrame(client, server, 1, 0, `{"MsgType":"NewOrder"}`+"\n"+`{"MsgType"`)},
+	}
+	factory := codec.GetDefaultMessageCodecFactory()
+	framer, _ := factory.GetFramer(codec.JSONLines)
+	c, _ := factory.GetCodec(codec.JSONLines)
+	records := Decode(Reassemble(packets, server.Port()), Options{Framer: framer, Codec: c})
+	assert.Len(t, records, 2)
+	assert.Equal(t, "NewOrder", records[0].MsgType)
+	assert.Equal(t, `{"MsgType"`, string(records[1].Raw))
+	assert.Contains(t, records[1].Error, "failed to frame 10 bytes")
+}
@@ -0,0 +1,253 @@
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// Link types of the captured frames
+const (
+	LinkTypeNull     = 0
+	LinkTypeEthernet = 1
+	LinkTypeRaw      = 101
+	LinkTypeLinuxSLL = 113
+	LinkTypeIPv4     = 228
+	LinkTypeIPv6     = 229
+	LinkTypeSLL2     = 276
+)
+
+const (
+	pcapMagicMicros = 0xa1b2c3d4
+	pcapMagicNanos  = 0xa1b23c4d
+
+	pcapngSectionHeader  = 0x0a0d0d0a
+	pcapngInterface      = 0x00000001
+	pcapngSimplePacket   = 0x00000003
+	pcapngEnhancedPacket = 0x00000006
+	pcapngByteOrderMagic = 0x1a2b3c4d
+	pcapngOptionTsResol  = 9
+	pcapngOptionEnd      = 0
+	maxBlockLen          = 64 << 20
+	defaultTsResolution  = time.Microsecond
+)
+
+// Packet is one captured frame
+type Packet struct {
+	Time     time.Time
+	LinkType uint32
+	Data     []byte
+}
+
+// ReadFile reads every packet of a pcap or pcapng file
+func ReadFile(path string) ([]Packet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	packets, err := Read(bufio.NewReader(file))
+	if err != nil {
+		return packets, fmt.Errorf("%s: %w", path, err)
+	}
+	return packets, nil
+}
+
+// Read reads every packet of a pcap or pcapng stream, the format is detected from its first bytes
+func Read(r io.Reader) ([]Packet, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, fmt.Errorf("failed to read file header: %w", err)
+	}
+	if binary.LittleEndian.Uint32(magic[:]) == pcapngSectionHeader {
+		return readPcapng(r)
+	}
+	return readPcap(r, magic)
+}
+
+func readPcap(r io.Reader, magic [4]byte) ([]Packet, error) {
+	var order binary.ByteOrder
+	var resolution time.Duration
+	switch {
+	case binary.LittleEndian.Uint32(magic[:]) == pcapMagicMicros:
+		order, resolution = binary.LittleEndian, time.Microsecond
+	case binary.BigEndian.Uint32(magic[:]) == pcapMagicMicros:
+		order, resolution = binary.BigEndian, time.Microsecond
+	case binary.LittleEndian.Uint32(magic[:]) == pcapMagicNanos:
+		order, resolution = binary.LittleEndian, time.Nanosecond
+	case binary.BigEndian.Uint32(magic[:]) == pcapMagicNanos:
+		order, resolution = binary.BigEndian, time.Nanosecond
+	default:
+		return nil, fmt.Errorf("not a pcap or pcapng file: magic %x", magic)
+	}
+	// version, thiszone, sigfigs, snaplen and link type
+	header := make([]byte, 20)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read file header: %w", err)
+	}
+	linkType := order.Uint32(header[16:]) & 0x0fffffff
+	var packets []Packet
+	record := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(r, record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return packets, nil
+			}
+			return packets, fmt.Errorf("truncated packet header: %w", err)
+		}
+		seconds, fraction := order.Uint32(record), order.Uint32(record[4:])
+		capLen := order.Uint32(record[8:])
+		if capLen > maxBlockLen {
+			return packets, fmt.Errorf("packet of %d bytes is too large", capLen)
+		}
+		data := make([]byte, capLen)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return packets, fmt.Errorf("truncated packet: %w", err)
+		}
+		packets = append(packets, Packet{
+			Time:     time.Unix(int64(seconds), int64(fraction)*int64(resolution)),
+			LinkType: linkType,
+			Data:     data,
+		})
+	}
+}
+
+// pcapngInterfaceDesc is what the packets need of an interface description block
+type pcapngInterfaceDesc struct {
+	linkType   uint32
+	resolution float64
+}
+
+func readPcapng(r io.Reader) ([]Packet, error) {
+	var order binary.ByteOrder = binary.LittleEndian
+	var interfaces []pcapngInterfaceDesc
+	var packets []Packet
+	blockType := uint32(pcapngSectionHeader)
+	for {
+		var lenBuf [4]byte
+		if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+			return packets, fmt.Errorf("truncated block header: %w", err)
+		}
+		if blockType == pcapngSectionHeader {
+			// the byte order of a section is known from its byte order magic, which follows the length
+			var bom [4]byte
+			if _, err := io.ReadFull(r, bom[:]); err != nil {
+				return packets, fmt.Errorf("truncated section header: %w", err)
+			}
+			switch {
+			case binary.LittleEndian.Uint32(bom[:]) == pcapngByteOrderMagic:
+				order = binary.LittleEndian
+			case binary.BigEndian.Uint32(bom[:]) == pcapngByteOrderMagic:
+				order = binary.BigEndian
+			default:
+				return packets, fmt.Errorf("invalid byte order magic %x", bom)
+			}
+			interfaces = nil
+			length := order.Uint32(lenBuf[:])
+			if length < 16 || length > maxBlockLen {
+				return packets, fmt.Errorf("invalid section header length %d", length)
+			}
+			if _, err := io.CopyN(io.Discard, r, int64(length)-12); err != nil {
+				return packets, fmt.Errorf("truncated section header: %w", err)
+			}
+		} else {
+			length := order.Uint32(lenBuf[:])
+			if length < 12 || length > maxBlockLen || length%4 != 0 {
+				return packets, fmt.Errorf("invalid block length %d", length)
+			}
+			body := make([]byte, length-12)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return packets, fmt.Errorf("truncated block: %w", err)
+			}
+			// trailing copy of the block length
+			if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+				return packets, fmt.Errorf("truncated block: %w", err)
+			}
+			switch blockType {
+			case pcapngInterface:
+				desc, err := parseInterfaceDesc(order, body)
+				if err != nil {
+					return packets, err
+				}
+				interfaces = append(interfaces, desc)
+			case pcapngEnhancedPacket:
+				if len(body) < 20 {
+					return packets, errors.New("truncated enhanced packet block")
+				}
+				id := order.Uint32(body)
+				if int(id) >= len(interfaces) {
+					return packets, fmt.Errorf("packet of unknown interface %d", id)
+				}
+				desc := interfaces[id]
+				ts := uint64(order.Uint32(body[4:]))<<32 | uint64(order.Uint32(body[8:]))
+				capLen := order.Uint32(body[12:])
+				if int(capLen) > len(body)-20 {
+					return packets, errors.New("truncated enhanced packet block")
+				}
+				packets = append(packets, Packet{
+					Time:     timestamp(ts, desc.resolution),
+					LinkType: desc.linkType,
+					Data:     body[20 : 20+capLen],
+				})
+			case pcapngSimplePacket:
+				// simple packets have no timestamp and belong to the first interface
+				if len(interfaces) == 0 || len(body) < 4 {
+					return packets, errors.New("invalid simple packet block")
+				}
+				capLen := min(int(order.Uint32(body)), len(body)-4)
+				packets = append(packets, Packet{LinkType: interfaces[0].linkType, Data: body[4 : 4+capLen]})
+			}
+		}
+		var typeBuf [4]byte
+		if _, err := io.ReadFull(r, typeBuf[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return packets, nil
+			}
+			return packets, fmt.Errorf("truncated block header: %w", err)
+		}
+		// the section header type reads the same in both byte orders
+		blockType = order.Uint32(typeBuf[:])
+	}
+}
+
+// parseInterfaceDesc reads the link type and the if_tsresol option of an interface description block
+func parseInterfaceDesc(order binary.ByteOrder, body []byte) (pcapngInterfaceDesc, error) {
+	if len(body) < 8 {
+		return pcapngInterfaceDesc{}, errors.New("truncated interface description block")
+	}
+	desc := pcapngInterfaceDesc{
+		linkType:   uint32(order.Uint16(body)),
+		resolution: float64(defaultTsResolution),
+	}
+	options := body[8:]
+	for len(options) >= 4 {
+		code, length := order.Uint16(options), int(order.Uint16(options[2:]))
+		if code == pcapngOptionEnd || 4+length > len(options) {
+			break
+		}
+		if code == pcapngOptionTsResol && length >= 1 {
+			value := options[4]
+			// the high bit selects a power of 2 instead of a power of 10
+			if value&0x80 != 0 {
+				desc.resolution = float64(time.Second) / math.Pow(2, float64(value&0x7f))
+			} else {
+				desc.resolution = float64(time.Second) / math.Pow(10, float64(value))
+			}
+		}
+		options = options[4+(length+3)/4*4:]
+	}
+	return desc, nil
+}
+
+// timestamp converts ticks of resolution nanoseconds
+func timestamp(ticks uint64, resolution float64) time.Time {
+	if resolution == float64(time.Microsecond) || resolution == float64(time.Nanosecond) {
+		return time.Unix(0, int64(ticks)*int64(resolution))
+	}
+	return time.Unix(0, int64(float64(ticks)*resolution))
+}
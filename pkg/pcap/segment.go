@@ -0,0 +1,188 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+const (
+	ethernetHeaderLen  = 14
+	linuxSLLHeaderLen  = 16
+	linuxSLL2HeaderLen = 20
+	nullHeaderLen      = 4
+	etherTypeIPv4      = 0x0800
+	etherTypeIPv6      = 0x86dd
+	etherTypeVLAN      = 0x8100
+	etherTypeQinQ      = 0x88a8
+	ipProtocolTCP      = 6
+	ipv6HopByHop       = 0
+	ipv6Routing        = 43
+	ipv6DestOptions    = 60
+
+	tcpFlagFin = 0x01
+	tcpFlagSyn = 0x02
+	tcpFlagRst = 0x04
+)
+
+// errNotTCP is returned for the packets that do not carry a TCP segment
+var errNotTCP = errors.New("not a TCP segment")
+
+// Segment is a TCP segment of a captured packet
+type Segment struct {
+	Time    time.Time
+	Src     netip.AddrPort
+	Dst     netip.AddrPort
+	Seq     uint32
+	Flags   uint8
+	Payload []byte
+}
+
+// ParseSegment extracts the TCP segment of a packet, errNotTCP is returned for other packets
+func ParseSegment(p Packet) (Segment, error) {
+	etherType, ip, err := linkPayload(p.LinkType, p.Data)
+	if err != nil {
+		return Segment{}, err
+	}
+	var src, dst netip.Addr
+	var tcp []byte
+	switch etherType {
+	case etherTypeIPv4:
+		src, dst, tcp, err = ipv4Payload(ip)
+	case etherTypeIPv6:
+		src, dst, tcp, err = ipv6Payload(ip)
+	default:
+		return Segment{}, errNotTCP
+	}
+	if err != nil {
+		return Segment{}, err
+	}
+	if len(tcp) < 20 {
+		return Segment{}, errors.New("truncated TCP header")
+	}
+	offset := int(tcp[12]>>4) * 4
+	if offset < 20 || offset > len(tcp) {
+		return Segment{}, fmt.Errorf("invalid TCP data offset %d", offset)
+	}
+	return Segment{
+		Time:    p.Time,
+		Src:     netip.AddrPortFrom(src, binary.BigEndian.Uint16(tcp)),
+		Dst:     netip.AddrPortFrom(dst, binary.BigEndian.Uint16(tcp[2:])),
+		Seq:     binary.BigEndian.Uint32(tcp[4:]),
+		Flags:   tcp[13],
+		Payload: tcp[offset:],
+	}, nil
+}
+
+// linkPayload strips the link layer header, returning the ether type of the payload
+func linkPayload(linkType uint32, data []byte) (uint16, []byte, error) {
+	switch linkType {
+	case LinkTypeEthernet:
+		if len(data) < ethernetHeaderLen {
+			return 0, nil, errors.New("truncated ethernet header")
+		}
+		etherType := binary.BigEndian.Uint16(data[12:])
+		data = data[ethernetHeaderLen:]
+		for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+			if len(data) < 4 {
+				return 0, nil, errors.New("truncated VLAN tag")
+			}
+			etherType = binary.BigEndian.Uint16(data[2:])
+			data = data[4:]
+		}
+		return etherType, data, nil
+	case LinkTypeLinuxSLL:
+		if len(data) < linuxSLLHeaderLen {
+			return 0, nil, errors.New("truncated linux cooked header")
+		}
+		return binary.BigEndian.Uint16(data[14:]), data[linuxSLLHeaderLen:], nil
+	case LinkTypeSLL2:
+		if len(data) < linuxSLL2HeaderLen {
+			return 0, nil, errors.New("truncated linux cooked header")
+		}
+		return binary.BigEndian.Uint16(data), data[linuxSLL2HeaderLen:], nil
+	case LinkTypeNull:
+		// the address family is in the byte order of the capturing host, the IP version tells it
+		if len(data) < nullHeaderLen {
+			return 0, nil, errors.New("truncated loopback header")
+		}
+		return rawEtherType(data[nullHeaderLen:]), data[nullHeaderLen:], nil
+	case LinkTypeRaw, LinkTypeIPv4, LinkTypeIPv6:
+		return rawEtherType(data), data, nil
+	default:
+		return 0, nil, fmt.Errorf("unsupported link type %d", linkType)
+	}
+}
+
+// rawEtherType tells the ether type of an IP packet from its version
+func rawEtherType(ip []byte) uint16 {
+	if len(ip) == 0 {
+		return 0
+	}
+	switch ip[0] >> 4 {
+	case 4:
+		return etherTypeIPv4
+	case 6:
+		return etherTypeIPv6
+	default:
+		return 0
+	}
+}
+
+func ipv4Payload(ip []byte) (netip.Addr, netip.Addr, []byte, error) {
+	if len(ip) < 20 {
+		return netip.Addr{}, netip.Addr{}, nil, errors.New("truncated IPv4 header")
+	}
+	headerLen := int(ip[0]&0x0f) * 4
+	totalLen := int(binary.BigEndian.Uint16(ip[2:]))
+	if headerLen < 20 || totalLen < headerLen {
+		return netip.Addr{}, netip.Addr{}, nil, errors.New("invalid IPv4 header")
+	}
+	if ip[9] != ipProtocolTCP {
+		return netip.Addr{}, netip.Addr{}, nil, errNotTCP
+	}
+	// more fragments flag or fragment offset
+	if binary.BigEndian.Uint16(ip[6:])&0x3fff != 0 {
+		return netip.Addr{}, netip.Addr{}, nil, errors.New("fragmented IPv4 packets are not supported")
+	}
+	// the ethernet padding of short packets follows the IP packet, segmentation offload may leave totalLen 0
+	if totalLen > 0 && totalLen < len(ip) {
+		ip = ip[:totalLen]
+	}
+	if headerLen > len(ip) {
+		return netip.Addr{}, netip.Addr{}, nil, errors.New("truncated IPv4 header")
+	}
+	src, _ := netip.AddrFromSlice(ip[12:16])
+	dst, _ := netip.AddrFromSlice(ip[16:20])
+	return src, dst, ip[headerLen:], nil
+}
+
+func ipv6Payload(ip []byte) (netip.Addr, netip.Addr, []byte, error) {
+	if len(ip) < 40 {
+		return netip.Addr{}, netip.Addr{}, nil, errors.New("truncated IPv6 header")
+	}
+	payloadLen := int(binary.BigEndian.Uint16(ip[4:]))
+	next := ip[6]
+	src, _ := netip.AddrFromSlice(ip[8:24])
+	dst, _ := netip.AddrFromSlice(ip[24:40])
+	payload := ip[40:]
+	if payloadLen > 0 && payloadLen < len(payload) {
+		payload = payload[:payloadLen]
+	}
+	for next == ipv6HopByHop || next == ipv6Routing || next == ipv6DestOptions {
+		if len(payload) < 8 {
+			return netip.Addr{}, netip.Addr{}, nil, errors.New("truncated IPv6 extension header")
+		}
+		length := (int(payload[1]) + 1) * 8
+		if length > len(payload) {
+			return netip.Addr{}, netip.Addr{}, nil, errors.New("truncated IPv6 extension header")
+		}
+		next, payload = payload[0], payload[length:]
+	}
+	if next != ipProtocolTCP {
+		return netip.Addr{}, netip.Addr{}, nil, errNotTCP
+	}
+	return src, dst, payload, nil
+}
@@ -0,0 +1,167 @@
+package pcap
+
+import (
+	"errors"
+	"net/netip"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Flow is the data sent in one direction of a TCP connection, in sequence order.
+// Retransmitted bytes are kept once, out of order segments wait for the missing ones.
+type Flow struct {
+	Data []byte
+	// Gaps counts the holes left by segments missing from the capture
+	Gaps    int
+	chunks  []chunk
+	started bool
+	next    uint32
+	pending map[uint32]Segment
+}
+
+// chunk is the time the data of Flow up to the end of the chunk starting at offset was all captured
+type chunk struct {
+	offset int
+	time   time.Time
+}
+
+// TimeAt returns when the data up to offset was all captured, so an out of order
+// segment is timed by the segment that filled the hole before it
+func (f *Flow) TimeAt(offset int) time.Time {
+	i := sort.Search(len(f.chunks), func(i int) bool { return f.chunks[i].offset > offset })
+	if i == 0 {
+		return time.Time{}
+	}
+	return f.chunks[i-1].time
+}
+
+func (f *Flow) add(s Segment) {
+	seq := s.Seq
+	if s.Flags&tcpFlagSyn != 0 {
+		// the SYN takes one sequence number, data it carries starts after it
+		seq++
+	}
+	if !f.started {
+		f.started = true
+		f.next = seq
+	}
+	if len(s.Payload) == 0 {
+		return
+	}
+	s.Seq = seq
+	if int32(seq-f.next) > 0 {
+		if f.pending == nil {
+			f.pending = make(map[uint32]Segment)
+		}
+		if prev, ok := f.pending[seq]; !ok || len(prev.Payload) < len(s.Payload) {
+			f.pending[seq] = s
+		}
+		return
+	}
+	f.append(s)
+	f.drain()
+}
+
+// append adds the part of s after the data already received
+func (f *Flow) append(s Segment) {
+	overlap := int(f.next - s.Seq)
+	if overlap >= len(s.Payload) {
+		return
+	}
+	t := s.Time
+	if n := len(f.chunks); n > 0 && f.chunks[n-1].time.After(t) {
+		t = f.chunks[n-1].time
+	}
+	f.chunks = append(f.chunks, chunk{offset: len(f.Data), time: t})
+	f.Data = append(f.Data, s.Payload[overlap:]...)
+	f.next += uint32(len(s.Payload) - overlap)
+}
+
+// drain appends the pending segments that became in order
+func (f *Flow) drain() {
+	for progress := true; progress; {
+		progress = false
+		for seq, s := range f.pending {
+			if int32(seq-f.next) <= 0 {
+				delete(f.pending, seq)
+				f.append(s)
+				progress = true
+			}
+		}
+	}
+}
+
+// finish appends the segments left pending after holes, skipping the missing data
+func (f *Flow) finish() {
+	for len(f.pending) > 0 {
+		first := true
+		var next uint32
+		for seq := range f.pending {
+			if first || int32(seq-next) < 0 {
+				next, first = seq, false
+			}
+		}
+		f.Gaps++
+		f.next = next
+		f.drain()
+	}
+}
+
+// Stream is a TCP connection, the client is the side connecting to the server port
+type Stream struct {
+	Session    int
+	Client     netip.AddrPort
+	Server     netip.AddrPort
+	FromClient Flow
+	FromServer Flow
+	closed     bool
+}
+
+// Reassemble rebuilds the TCP connections to port from the captured packets.
+// Sessions are numbered from 1 in the order of their first packet, a new SYN
+// after the end of a connection starts a new session on the same addresses.
+func Reassemble(packets []Packet, port uint16) []*Stream {
+	var streams []*Stream
+	active := make(map[[2]netip.AddrPort]*Stream)
+	for _, p := range packets {
+		s, err := ParseSegment(p)
+		if err != nil {
+			if !errors.Is(err, errNotTCP) {
+				log.Warnf("Skipping packet at %s: %s", p.Time, err)
+			}
+			continue
+		}
+		var key [2]netip.AddrPort
+		fromClient := s.Dst.Port() == port
+		switch {
+		case fromClient:
+			key = [2]netip.AddrPort{s.Src, s.Dst}
+		case s.Src.Port() == port:
+			key = [2]netip.AddrPort{s.Dst, s.Src}
+		default:
+			continue
+		}
+		stream := active[key]
+		newConnection := fromClient && s.Flags&tcpFlagSyn != 0
+		if stream == nil || (newConnection && (stream.closed || len(stream.FromClient.Data) > 0)) {
+			stream = &Stream{Session: len(streams) + 1, Client: key[0], Server: key[1]}
+			streams = append(streams, stream)
+			active[key] = stream
+		}
+		if fromClient {
+			stream.FromClient.add(s)
+		} else {
+			stream.FromServer.add(s)
+		}
+		if s.Flags&(tcpFlagFin|tcpFlagRst) != 0 {
+			stream.closed = true
+		}
+	}
+	for _, stream := range streams {
+		stream.FromClient.finish()
+		stream.FromServer.finish()
+	}
+	return streams
+}
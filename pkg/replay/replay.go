@@ -0,0 +1,244 @@
+package replay
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	gt_codec "github.com/xinchentechnote/gt-auto/pkg/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+// defaultTimeout is how long a recorded response is waited for
+const defaultTimeout = 5 * time.Second
+
+// pollInterval is how often the simulator is polled for a response
+const pollInterval = 10 * time.Millisecond
+
+// Endpoint is a simulator replaying the records of one capture source
+// Side, capture.FromClient or capture.FromServer, the side played by the simulator:
+// its recorded messages are sent and the messages of the other side are expected,
+// an oms simulator plays the client side of the gateway's clients and a tgw simulator
+// the server side of its exchange link, answering with the recorded replies
+type Endpoint struct {
+	Source    string
+	Side      string
+	Simulator tcp.Simulator[codec.BinaryCodec]
+}
+
+// Options controls a replay
+// Session, only the records of this session are replayed, all of them when 0
+// Speed, the factor applied to the recorded timing, 2 replays twice as fast, 0 sends without waiting
+// Timeout, how long each expected message is waited for, 5s when 0
+// Ignore, fields not compared, nested fields included
+// IgnoreTimes, fields whose name ends with Time are not compared
+type Options struct {
+	Session     int
+	Speed       float64
+	Timeout     time.Duration
+	Ignore      []string
+	IgnoreTimes bool
+}
+
+// Divergence is an expected message that was not received as recorded
+// Detail holds the differences of the message received instead, Error why none was
+type Divergence struct {
+	Index    int
+	Expected capture.Record
+	Detail   validate.CompareResult
+	Error    string
+}
+
+// Report is the outcome of a replay
+// Unexpected holds the messages received after the last expected one, by the simulator of their Source
+type Report struct {
+	Sent        int
+	Expected    int
+	Matched     int
+	Divergences []Divergence
+	Unexpected  []capture.Record
+}
+
+// Passed tells whether every expected message was received as recorded and nothing more
+func (r *Report) Passed() bool {
+	return len(r.Divergences) == 0 && len(r.Unexpected) == 0
+}
+
+// Replay replays the records of each endpoint's source in recorded order: the records of the
+// endpoint's side are sent with their recorded timing, those of the other side are expected from
+// its simulator and compared, so the messages the gateway forwards to a tgw endpoint are checked
+// before the recorded exchange replies are sent back. Records of other sources are skipped.
+func Replay(endpoints []Endpoint, records []capture.Record, opts Options) (*Report, error) {
+	bySource := make(map[string]Endpoint, len(endpoints))
+	for _, e := range endpoints {
+		if e.Side != capture.FromClient && e.Side != capture.FromServer {
+			return nil, fmt.Errorf("%s: unknown side: %q, expected %s or %s", e.Source, e.Side, capture.FromClient, capture.FromServer)
+		}
+		if _, ok := bySource[e.Source]; ok {
+			return nil, fmt.Errorf("source %s is replayed twice", e.Source)
+		}
+		bySource[e.Source] = e
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	report := &Report{}
+	var first time.Time
+	start := time.Now()
+	for i, r := range records {
+		endpoint, ok := bySource[r.Source]
+		if !ok || (opts.Session != 0 && r.Session != opts.Session) {
+			continue
+		}
+		if r.Message == nil {
+			log.Warnf("Skipping undecoded record %d: %s", i, r.Error)
+			continue
+		}
+		if first.IsZero() {
+			first = r.Time
+		}
+		if r.From == endpoint.Side {
+			if opts.Speed > 0 {
+				due := start.Add(time.Duration(float64(r.Time.Sub(first)) / opts.Speed))
+				time.Sleep(time.Until(due))
+			}
+			if err := endpoint.Simulator.SendFromJSON(messageOf(r)); err != nil {
+				return report, fmt.Errorf("failed to send record %d: %w", i, err)
+			}
+			report.Sent++
+			continue
+		}
+		report.Expected++
+		if divergence, ok := expect(endpoint.Simulator, i, r, opts); ok {
+			report.Divergences = append(report.Divergences, divergence)
+		} else {
+			report.Matched++
+		}
+	}
+	// whatever arrives shortly after the last expected message was not recorded
+	time.Sleep(min(opts.Timeout, time.Second))
+	for _, e := range endpoints {
+		report.Unexpected = append(report.Unexpected, drain(e)...)
+	}
+	return report, nil
+}
+
+// drain returns the messages waiting at the endpoint's simulator as records of the other side
+func drain(e Endpoint) []capture.Record {
+	from := capture.FromServer
+	if e.Side == capture.FromServer {
+		from = capture.FromClient
+	}
+	var records []capture.Record
+	for {
+		msg, _ := e.Simulator.Receive()
+		if msg == nil {
+			return records
+		}
+		fields, err := gt_codec.MessageToMap(msg)
+		if err != nil {
+			fields = map[string]interface{}{"": fmt.Sprint(msg)}
+		}
+		msgType, _ := fields["MsgType"].(string)
+		records = append(records, capture.Record{
+			Time:    time.Now(),
+			Source:  e.Source,
+			From:    from,
+			MsgType: msgType,
+			Message: fields,
+		})
+	}
+}
+
+// messageOf returns the recorded message with its MsgType, which the codecs encode by
+func messageOf(r capture.Record) map[string]interface{} {
+	message := make(map[string]interface{}, len(r.Message)+1)
+	for k, v := range r.Message {
+		message[k] = v
+	}
+	if _, ok := message["MsgType"]; !ok && r.MsgType != "" {
+		message["MsgType"] = r.MsgType
+	}
+	return message
+}
+
+// expect waits for the next message and compares it with the record, it returns the divergence if any
+func expect(sim tcp.Simulator[codec.BinaryCodec], index int, r capture.Record, opts Options) (Divergence, bool) {
+	divergence := Divergence{Index: index, Expected: r}
+	msg, fault := receive(sim, opts.Timeout)
+	if msg == nil {
+		divergence.Error = fmt.Sprintf("no %s received within %s", r.MsgType, opts.Timeout)
+		return divergence, true
+	}
+	actual, err := gt_codec.MessageToMap(msg)
+	if err != nil {
+		divergence.Error = fmt.Sprintf("cannot compare %T: %s", msg, err)
+		return divergence, true
+	}
+	// both sides are converted by MessageToMap, so they carry MsgType alike
+	result := validate.CompareMap(r.Message, actual).Ignore(opts.Ignore...)
+	if opts.IgnoreTimes {
+		result = ignoreTimes(result)
+	}
+	if fault != nil {
+		divergence.Error = fault.Error()
+	}
+	divergence.Detail = result
+	return divergence, !result.Equal || fault != nil
+}
+
+// receive polls the simulator until a message arrives or timeout, a checksum fault is returned with the message
+func receive(sim tcp.Simulator[codec.BinaryCodec], timeout time.Duration) (codec.BinaryCodec, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		msg, err := sim.Receive()
+		if msg != nil {
+			return msg, err
+		}
+		if time.Now().After(deadline) {
+			return nil, nil
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func ignoreTimes(result validate.CompareResult) validate.CompareResult {
+	var fields []string
+	for _, diff := range result.Diffs {
+		segments := strings.Split(diff.Path, ".")
+		if last := segments[len(segments)-1]; validate.IsTimeField(last) {
+			fields = append(fields, last)
+		}
+	}
+	return result.Ignore(fields...)
+}
+
+// Print writes the divergences as tables and a summary line
+func (r *Report) Print(w io.Writer) {
+	for _, d := range r.Divergences {
+		fmt.Fprintf(w, "❌ record %d, %s session %d, %s %s at %s\n", d.Index, d.Expected.Source, d.Expected.Session,
+			d.Expected.From, d.Expected.MsgType, d.Expected.Time.Format(time.RFC3339Nano))
+		if d.Error != "" {
+			fmt.Fprintf(w, "   %s\n", d.Error)
+		}
+		if len(d.Detail.Diffs) > 0 {
+			table := tablewriter.NewWriter(w)
+			table.SetHeader([]string{"Path", "Expected", "Actual"})
+			for _, diff := range d.Detail.Diffs {
+				table.Append([]string{diff.Path, fmt.Sprintf("%v", diff.Expect), fmt.Sprintf("%v", diff.Actual)})
+			}
+			table.Render()
+		}
+	}
+	for _, m := range r.Unexpected {
+		fmt.Fprintf(w, "❌ unexpected message at %s: %v\n", m.Source, m.Message)
+	}
+	fmt.Fprintf(w, "Sent %d, expected %d, matched %d, diverged %d, unexpected %d\n",
+		r.Sent, r.Expected, r.Matched, len(r.Divergences), len(r.Unexpected))
+}
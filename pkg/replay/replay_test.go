@@ -0,0 +1,200 @@
+package replay
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	gt_codec "github.com/xinchentechnote/gt-auto/pkg/codec"
+)
+
+// gatewaySimulator answers every message sent with the responses of respond
+type gatewaySimulator struct {
+	codec   gt_codec.MessageCodec
+	respond func(map[string]interface{}) []map[string]interface{}
+	mu      sync.Mutex
+	sent    []time.Time
+	queue   []codec.BinaryCodec
+}
+
+func (s *gatewaySimulator) Start() error                                      { return nil }
+func (s *gatewaySimulator) Close() error                                      { return nil }
+func (s *gatewaySimulator) GetCodec() gt_codec.MessageCodec                   { return s.codec }
+func (s *gatewaySimulator) Send(ext interface{}, msg codec.BinaryCodec) error { return nil }
+
+func (s *gatewaySimulator) SendFromJSON(message map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, time.Now())
+	for _, response := range s.respond(message) {
+		msg, err := s.codec.JSONToStruct(response)
+		if err != nil {
+			return err
+		}
+		s.queue = append(s.queue, msg)
+	}
+	return nil
+}
+
+// push queues message as if the gateway had sent it to the simulator
+func (s *gatewaySimulator) push(message map[string]interface{}) error {
+	msg, err := s.codec.JSONToStruct(message)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, msg)
+	return nil
+}
+
+func (s *gatewaySimulator) Receive() (codec.BinaryCodec, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return nil, errors.New("empty queue")
+	}
+	msg := s.queue[0]
+	s.queue = s.queue[1:]
+	return msg, nil
+}
+
+func newGateway(t *testing.T, respond func(map[string]interface{}) []map[string]interface{}) *gatewaySimulator {
+	c, err := gt_codec.GetDefaultMessageCodecFactory().GetCodec(gt_codec.JSONLines)
+	assert.NoError(t, err)
+	return &gatewaySimulator{codec: c, respond: respond}
+}
+
+func loadRecording(t *testing.T) []capture.Record {
+	return loadCapture(t, "recording.jsonl")
+}
+
+func loadCapture(t *testing.T, name string) []capture.Record {
+	records, err := capture.ReadFile(filepath.Join("testdata", name))
+	assert.NoError(t, err)
+	return records
+}
+
+// acknowledge is the recorded gateway behaviour, orderID is what the new build sets as OrderID
+func acknowledge(orderID string) func(map[string]interface{}) []map[string]interface{} {
+	return func(m map[string]interface{}) []map[string]interface{} {
+		return []map[string]interface{}{{
+			"MsgType":      "ExecutionReport",
+			"ClOrdID":      m["ClOrdID"],
+			"OrderID":      orderID,
+			"TransactTime": time.Now().Format("150405.000"),
+		}}
+	}
+}
+
+// client replays the client side of the recording with gateway
+func client(gateway *gatewaySimulator) []Endpoint {
+	return []Endpoint{{Source: "proxy", Side: capture.FromClient, Simulator: gateway}}
+}
+
+func TestReplayMatches(t *testing.T) {
+	gateway := newGateway(t, acknowledge("o1"))
+	report, err := Replay(client(gateway), loadRecording(t), Options{Speed: 1, Timeout: time.Second, IgnoreTimes: true})
+	assert.NoError(t, err)
+	assert.True(t, report.Passed())
+	assert.Equal(t, 2, report.Sent)
+	assert.Equal(t, 2, report.Matched)
+	// the second order was recorded 200ms after the first one
+	assert.GreaterOrEqual(t, gateway.sent[1].Sub(gateway.sent[0]), 180*time.Millisecond)
+}
+
+func TestReplayDivergences(t *testing.T) {
+	gateway := newGateway(t, acknowledge("o2"))
+	records := loadRecording(t)
+	report, err := Replay(client(gateway), records, Options{Timeout: 100 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.False(t, report.Passed())
+	assert.Len(t, report.Divergences, 2)
+	var paths []string
+	for _, diff := range report.Divergences[0].Detail.Diffs {
+		paths = append(paths, diff.Path)
+	}
+	assert.ElementsMatch(t, []string{"OrderID", "TransactTime"}, paths)
+
+	report, err = Replay(client(gateway), records, Options{Timeout: 100 * time.Millisecond, IgnoreTimes: true, Ignore: []string{"OrderID"}})
+	assert.NoError(t, err)
+	assert.True(t, report.Passed())
+
+	var out strings.Builder
+	report.Print(&out)
+	assert.Equal(t, "Sent 2, expected 2, matched 2, diverged 0, unexpected 0\n", out.String())
+}
+
+func TestReplayMissingAndUnexpected(t *testing.T) {
+	calls := 0
+	gateway := newGateway(t, func(m map[string]interface{}) []map[string]interface{} {
+		calls++
+		if calls == 1 {
+			return nil
+		}
+		return append(acknowledge("o1")(m), map[string]interface{}{"MsgType": "Heartbeat"})
+	})
+	report, err := Replay(client(gateway), loadRecording(t), Options{Timeout: 100 * time.Millisecond, IgnoreTimes: true})
+	assert.NoError(t, err)
+	assert.Len(t, report.Divergences, 1)
+	assert.Equal(t, 1, report.Divergences[0].Index)
+	assert.Contains(t, report.Divergences[0].Error, "no ExecutionReport received")
+	assert.Equal(t, 1, report.Matched)
+	assert.Len(t, report.Unexpected, 1)
+	assert.Equal(t, "Heartbeat", report.Unexpected[0].MsgType)
+	assert.Equal(t, "proxy", report.Unexpected[0].Source)
+
+	_, err = Replay([]Endpoint{{Source: "proxy", Side: "gateway", Simulator: gateway}}, nil, Options{})
+	assert.Error(t, err)
+}
+
+// linkGateway returns an oms and a tgw simulator between which a gateway forwards orders
+// to the exchange, applying forward to them, and forwards the exchange's reports back
+func linkGateway(t *testing.T, forward func(map[string]interface{}) map[string]interface{}) (*gatewaySimulator, *gatewaySimulator) {
+	var oms, tgw *gatewaySimulator
+	oms = newGateway(t, func(m map[string]interface{}) []map[string]interface{} {
+		assert.NoError(t, tgw.push(forward(m)))
+		return nil
+	})
+	tgw = newGateway(t, func(m map[string]interface{}) []map[string]interface{} {
+		assert.NoError(t, oms.push(m))
+		return nil
+	})
+	return oms, tgw
+}
+
+func TestReplayThroughExchange(t *testing.T) {
+	records := loadCapture(t, "exchange.jsonl")
+	oms, tgw := linkGateway(t, func(m map[string]interface{}) map[string]interface{} { return m })
+	endpoints := []Endpoint{
+		{Source: "oms-proxy", Side: capture.FromClient, Simulator: oms},
+		{Source: "tgw-proxy", Side: capture.FromServer, Simulator: tgw},
+	}
+	report, err := Replay(endpoints, records, Options{Timeout: 100 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.True(t, report.Passed())
+	// the order by the oms simulator, the exchange reply by the tgw simulator
+	assert.Equal(t, 2, report.Sent)
+	assert.Equal(t, 2, report.Matched)
+
+	oms, tgw = linkGateway(t, func(m map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"MsgType": m["MsgType"], "ClOrdID": m["ClOrdID"], "OrderQty": 200}
+	})
+	endpoints[0].Simulator, endpoints[1].Simulator = oms, tgw
+	report, err = Replay(endpoints, records, Options{Timeout: 100 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.Len(t, report.Divergences, 1)
+	assert.Equal(t, 1, report.Divergences[0].Index)
+	assert.Equal(t, "tgw-proxy", report.Divergences[0].Expected.Source)
+	assert.Equal(t, "OrderQty", report.Divergences[0].Detail.Diffs[0].Path)
+	assert.Equal(t, 1, report.Matched)
+
+	_, err = Replay(append(endpoints, endpoints[0]), records, Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package tcp
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ConnectionEventType is the kind of a ConnectionEvent
+type ConnectionEventType string
+
+const (
+	// Connected a connection is established, accepted by a server or dialed by a client
+	Connected ConnectionEventType = "Connected"
+	// Disconnected a connection is closed by either side
+	Disconnected ConnectionEventType = "Disconnected"
+)
+
+// ConnectionEvent records a connection change of a simulator
+type ConnectionEvent struct {
+	Type    ConnectionEventType
+	Session SessionInfo
+	Time    time.Time
+}
+
+// ConnectionEvents is the log of the connection events of a simulator, the zero value is ready to use.
+type ConnectionEvents struct {
+	mu      sync.Mutex
+	events  []ConnectionEvent
+	changed chan struct{}
+}
+
+// record appends an event and wakes up the waiters
+func (l *ConnectionEvents) record(eventType ConnectionEventType, session SessionInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, ConnectionEvent{Type: eventType, Session: session, Time: time.Now()})
+	if l.changed != nil {
+		close(l.changed)
+		l.changed = nil
+	}
+}
+
+// All returns the events recorded so far
+func (l *ConnectionEvents) All() []ConnectionEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]ConnectionEvent(nil), l.events...)
+}
+
+// Wait returns the first event of eventType on session recorded after since,
+// waiting up to timeout for it. An empty session matches every session.
+func (l *ConnectionEvents) Wait(eventType ConnectionEventType, session string, since time.Time, timeout time.Duration) (ConnectionEvent, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		l.mu.Lock()
+		for _, event := range l.events {
+			if event.Type == eventType && !event.Time.Before(since) && (session == "" || event.Session.Matches(session)) {
+				l.mu.Unlock()
+				return event, nil
+			}
+		}
+		if l.changed == nil {
+			l.changed = make(chan struct{})
+		}
+		changed := l.changed
+		l.mu.Unlock()
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return ConnectionEvent{}, fmt.Errorf("no %s event within %s", eventType, timeout)
+		}
+	}
+}
+
+// Matches reports whether session is the ID, the CompID or the Index of s
+func (s SessionInfo) Matches(session string) bool {
+	return s.ID == session || (s.CompID != "" && s.CompID == session) || strconv.Itoa(s.Index) == session
+}
+
+// Disconnector is a simulator able to close its connections on purpose
+// session selects the connection of a SessionSimulator, empty for the last one
+type Disconnector interface {
+	Disconnect(session string) error
+}
+
+// Reconnector is a client simulator able to drop its connection and dial again
+type Reconnector interface {
+	Reconnect() error
+}
+
+// ListenerController is a server simulator whose listener can be stopped and started again,
+// the sessions already accepted are left open
+type ListenerController interface {
+	StopListener() error
+	StartListener() error
+}
+
+// AddrReporter is a server simulator reporting the address it listens on,
+// with the port picked by the system when listen_address has port 0
+type AddrReporter interface {
+	Addr() string
+}
+
+// EventSource is a simulator recording its ConnectionEvents
+type EventSource interface {
+	Events() *ConnectionEvents
+}
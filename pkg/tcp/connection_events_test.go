@@ -0,0 +1,57 @@
+package tcp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+)
+
+func TestConnectionLifecycle(t *testing.T) {
+	sim, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "tgw", Protocol: "json-lines", ListenAddress: "127.0.0.1:0",
+	})
+	require.NoError(t, err)
+	tgw := sim.(*tcp.TgwSimulator[codec.BinaryCodec])
+	go tgw.Start()
+	t.Cleanup(func() { tgw.Close() })
+	sim, err = tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "oms", Protocol: "json-lines", ServerAddress: listenAddr(t, tgw),
+	})
+	require.NoError(t, err)
+	oms := sim.(*tcp.OmsSimulator[codec.BinaryCodec])
+
+	start := time.Now()
+	require.NoError(t, oms.Start())
+	defer oms.Close()
+	event, err := tgw.Events().Wait(tcp.Connected, "1", start, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, event.Session.Index)
+
+	// the exchange drops the gateway, both sides see the disconnect
+	mark := time.Now()
+	require.NoError(t, tgw.Disconnect(""))
+	_, err = tgw.Events().Wait(tcp.Disconnected, "1", mark, time.Second)
+	require.NoError(t, err)
+	_, err = oms.Events().Wait(tcp.Disconnected, "", mark, time.Second)
+	require.NoError(t, err)
+
+	// no reconnect while the listener is down
+	require.NoError(t, tgw.StopListener())
+	assert.Error(t, oms.Reconnect())
+	require.NoError(t, tgw.StartListener())
+	mark = time.Now()
+	require.NoError(t, oms.Reconnect())
+	event, err = tgw.Events().Wait(tcp.Connected, "", mark, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 2, event.Session.Index)
+	_, err = oms.Events().Wait(tcp.Connected, "2", mark, time.Second)
+	require.NoError(t, err)
+
+	_, err = tgw.Events().Wait(tcp.Disconnected, "2", mark, 50*time.Millisecond)
+	assert.ErrorContains(t, err, "no Disconnected event")
+}
@@ -0,0 +1,163 @@
+package tcp
+
+import (
+	"errors"
+	"log"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+)
+
+// defaultCoalesceWait is how long a partial group of coalesced frames waits for the next frame
+const defaultCoalesceWait = 100 * time.Millisecond
+
+// errFaultReset is returned when a write is replaced by a connection reset
+var errFaultReset = errors.New("connection reset by fault injection")
+
+// FaultInjectable is a simulator whose sent frames go through a FaultInjector
+type FaultInjectable interface {
+	SetFaults(faults config.FaultConfig)
+}
+
+// FaultInjector writes frames to a connection with the configured network faults,
+// the zero value writes every frame as-is.
+type FaultInjector struct {
+	mu      sync.Mutex
+	faults  config.FaultConfig
+	rand    *rand.Rand
+	pending []byte
+	frames  int
+	conn    net.Conn
+	timer   *time.Timer
+}
+
+// Set replaces the faults, frames waiting to be coalesced are written first
+func (f *FaultInjector) Set(faults config.FaultConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.flush(); err != nil {
+		log.Printf("Error writing coalesced frames: %v", err)
+	}
+	f.faults = faults
+	seed := faults.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	f.rand = rand.New(rand.NewSource(seed))
+}
+
+// Write writes one frame to conn
+func (f *FaultInjector) Write(conn net.Conn, frame []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.faults == (config.FaultConfig{}) {
+		_, err := conn.Write(frame)
+		return err
+	}
+	if f.conn != conn {
+		if err := f.flush(); err != nil {
+			log.Printf("Error writing coalesced frames: %v", err)
+		}
+	}
+	if f.faults.ResetRate > 0 && f.rand.Float64() < f.faults.ResetRate {
+		f.pending, f.frames = nil, 0
+		reset(conn)
+		return errFaultReset
+	}
+	data := append([]byte(nil), frame...)
+	if f.faults.CorruptRate > 0 {
+		for i := range data {
+			if f.rand.Float64() < f.faults.CorruptRate {
+				data[i] ^= 0xFF
+			}
+		}
+	}
+	if f.faults.Coalesce > 1 {
+		f.conn = conn
+		f.pending = append(f.pending, data...)
+		f.frames++
+		if f.frames < f.faults.Coalesce {
+			f.scheduleFlush()
+			return nil
+		}
+		return f.flush()
+	}
+	return f.write(conn, data)
+}
+
+// scheduleFlush writes a partial group once the coalesce wait is over
+func (f *FaultInjector) scheduleFlush() {
+	if f.timer != nil {
+		return
+	}
+	wait := defaultCoalesceWait
+	if f.faults.CoalesceWaitMs > 0 {
+		wait = time.Duration(f.faults.CoalesceWaitMs) * time.Millisecond
+	}
+	f.timer = time.AfterFunc(wait, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.timer = nil
+		if err := f.flush(); err != nil {
+			log.Printf("Error writing coalesced frames: %v", err)
+		}
+	})
+}
+
+// flush writes the coalesced frames, the caller holds mu
+func (f *FaultInjector) flush() error {
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	data, conn := f.pending, f.conn
+	f.pending, f.frames, f.conn = nil, 0, nil
+	if len(data) == 0 || conn == nil {
+		return nil
+	}
+	return f.write(conn, data)
+}
+
+// write applies latency, splitting and throttling, the caller holds mu
+func (f *FaultInjector) write(conn net.Conn, data []byte) error {
+	delay := time.Duration(f.faults.LatencyMs) * time.Millisecond
+	if f.faults.JitterMs > 0 {
+		delay += time.Duration(f.rand.Int63n(int64(f.faults.JitterMs)+1)) * time.Millisecond
+	}
+	time.Sleep(delay)
+	chunk := len(data)
+	if f.faults.SplitBytes > 0 && f.faults.SplitBytes < chunk {
+		chunk = f.faults.SplitBytes
+	}
+	if bps := f.faults.BandwidthBps; bps > 0 && bps/10 < chunk {
+		// a tenth of a second worth of bytes per write
+		chunk = max(bps/10, 1)
+	}
+	for len(data) > 0 {
+		n := min(chunk, len(data))
+		if _, err := conn.Write(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+		if f.faults.BandwidthBps > 0 {
+			time.Sleep(time.Duration(n) * time.Second / time.Duration(f.faults.BandwidthBps))
+		}
+		if len(data) > 0 && f.faults.SplitGapMs > 0 {
+			time.Sleep(time.Duration(f.faults.SplitGapMs) * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// reset closes conn with an RST instead of a FIN when it is a TCP connection
+func reset(conn net.Conn) {
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		if err := tcpConn.SetLinger(0); err != nil {
+			log.Printf("Error setting linger: %v", err)
+		}
+	}
+	conn.Close()
+}
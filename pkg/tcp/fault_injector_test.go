@@ -0,0 +1,128 @@
+package tcp_test
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+// recordingConn records every write
+type recordingConn struct {
+	net.Conn
+	mu     sync.Mutex
+	writes [][]byte
+	closed bool
+}
+
+func (c *recordingConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes = append(c.writes, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func (c *recordingConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *recordingConn) recorded() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]byte(nil), c.writes...)
+}
+
+func TestFaultInjector(t *testing.T) {
+	frame := []byte("0123456789")
+
+	t.Run("none", func(t *testing.T) {
+		var f tcp.FaultInjector
+		conn := &recordingConn{}
+		require.NoError(t, f.Write(conn, frame))
+		assert.Equal(t, [][]byte{frame}, conn.recorded())
+	})
+
+	t.Run("split", func(t *testing.T) {
+		var f tcp.FaultInjector
+		f.Set(config.FaultConfig{SplitBytes: 3})
+		conn := &recordingConn{}
+		require.NoError(t, f.Write(conn, frame))
+		assert.Equal(t, [][]byte{[]byte("012"), []byte("345"), []byte("678"), []byte("9")}, conn.recorded())
+	})
+
+	t.Run("coalesce", func(t *testing.T) {
+		var f tcp.FaultInjector
+		f.Set(config.FaultConfig{Coalesce: 2, CoalesceWaitMs: 20})
+		conn := &recordingConn{}
+		require.NoError(t, f.Write(conn, []byte("ab")))
+		assert.Empty(t, conn.recorded())
+		require.NoError(t, f.Write(conn, []byte("cd")))
+		assert.Equal(t, [][]byte{[]byte("abcd")}, conn.recorded())
+		// a partial group is written after the wait
+		require.NoError(t, f.Write(conn, []byte("ef")))
+		assert.Eventually(t, func() bool { return len(conn.recorded()) == 2 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		var f tcp.FaultInjector
+		f.Set(config.FaultConfig{CorruptRate: 1})
+		conn := &recordingConn{}
+		require.NoError(t, f.Write(conn, []byte{0x00, 0x0F}))
+		assert.Equal(t, [][]byte{{0xFF, 0xF0}}, conn.recorded())
+		assert.Equal(t, byte('0'), frame[0], "the frame of the caller is left intact")
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		var f tcp.FaultInjector
+		f.Set(config.FaultConfig{ResetRate: 1})
+		conn := &recordingConn{}
+		assert.ErrorContains(t, f.Write(conn, frame), "reset by fault injection")
+		assert.Empty(t, conn.recorded())
+		assert.True(t, conn.closed)
+	})
+
+	t.Run("latency and bandwidth", func(t *testing.T) {
+		var f tcp.FaultInjector
+		f.Set(config.FaultConfig{LatencyMs: 20, BandwidthBps: 200})
+		conn := &recordingConn{}
+		start := time.Now()
+		require.NoError(t, f.Write(conn, frame))
+		// 20ms of latency then 10 bytes at 200 bytes per second
+		assert.GreaterOrEqual(t, time.Since(start), 70*time.Millisecond)
+		assert.Len(t, conn.recorded(), 1)
+	})
+}
+
+func TestSplitFramesAreReassembled(t *testing.T) {
+	sim, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "tgw", Protocol: "json-lines", ListenAddress: "127.0.0.1:0",
+		Faults: config.FaultConfig{SplitBytes: 2, SplitGapMs: 1},
+	})
+	require.NoError(t, err)
+	go sim.Start()
+	t.Cleanup(func() { sim.Close() })
+	address := listenAddr(t, sim)
+
+	oms, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "oms", Protocol: "json-lines", ServerAddress: address,
+	})
+	require.NoError(t, err)
+	require.NoError(t, oms.Start())
+	defer oms.Close()
+	require.NoError(t, oms.SendFromJSON(map[string]interface{}{"MsgType": "Logon"}))
+	receiveWithin(t, sim)
+
+	ack := map[string]interface{}{"MsgType": "Ack", "ClOrdID": "1"}
+	require.NoError(t, sim.SendFromJSON(ack))
+	assert.True(t, validate.CompareStruct(ack, receiveWithin(t, oms)).Equal)
+}
@@ -0,0 +1,48 @@
+package tcp
+
+import (
+	"sync"
+	"time"
+)
+
+// FrameTimer is implemented by the simulators that time the frames they write and read,
+// so hop latencies do not include the time messages wait in the receive queue.
+type FrameTimer interface {
+	// LastSent returns when the frame of the last message sent was written
+	LastSent() time.Time
+	// LastReceived returns when the frame of the message last returned by Receive was read
+	LastReceived() time.Time
+}
+
+// frameTimes implements FrameTimer for the simulators embedding it
+type frameTimes struct {
+	mu       sync.Mutex
+	sent     time.Time
+	received time.Time
+}
+
+// LastSent implements FrameTimer
+func (t *frameTimes) LastSent() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sent
+}
+
+// LastReceived implements FrameTimer
+func (t *frameTimes) LastReceived() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.received
+}
+
+func (t *frameTimes) markSent(at time.Time) {
+	t.mu.Lock()
+	t.sent = at
+	t.mu.Unlock()
+}
+
+func (t *frameTimes) markReceived(at time.Time) {
+	t.mu.Lock()
+	t.received = at
+	t.mu.Unlock()
+}
@@ -0,0 +1,48 @@
+package tcp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+)
+
+func TestFrameTimer(t *testing.T) {
+	tgw, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "tgw", Protocol: "json-lines", ListenAddress: "127.0.0.1:0",
+	})
+	require.NoError(t, err)
+	defer tgw.Close()
+	go tgw.Start()
+	address := listenAddr(t, tgw)
+	oms, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "oms", Protocol: "json-lines", ServerAddress: address,
+	})
+	require.NoError(t, err)
+	defer oms.Close()
+	require.NoError(t, oms.Start())
+	time.Sleep(100 * time.Millisecond)
+
+	omsTimer, ok := oms.(tcp.FrameTimer)
+	require.True(t, ok)
+	tgwTimer := tgw.(tcp.FrameTimer)
+	assert.True(t, omsTimer.LastSent().IsZero())
+
+	require.NoError(t, oms.SendFromJSON(map[string]interface{}{"MsgType": "NewOrder", "ClOrdID": "c1"}))
+	sent := omsTimer.LastSent()
+	assert.False(t, sent.IsZero())
+	// the message waits in the queue, its frame was read before Receive
+	time.Sleep(200 * time.Millisecond)
+	dequeued := time.Now()
+	msg, err := tgw.Receive()
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	read := tgwTimer.LastReceived()
+	assert.True(t, read.After(sent))
+	assert.True(t, read.Before(dequeued))
+	assert.Less(t, read.Sub(sent), 100*time.Millisecond)
+}
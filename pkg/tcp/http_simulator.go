@@ -0,0 +1,314 @@
+package tcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enriquebris/goconcurrentqueue"
+	fin_codec "github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/codec"
+)
+
+// httpTimeout bounds every request of the HTTP client simulator
+const httpTimeout = 10 * time.Second
+
+// HTTPOmsSimulator is an HTTP client, every Send issues one request to ServerAddress
+// and queues the response for Receive as a message with Status and Body.
+// A message sent holds Method, Path, Headers and Body, Method defaults to POST with a body
+// and GET without.
+type HTTPOmsSimulator[T fin_codec.BinaryCodec] struct {
+	ServerAddress string
+	client        *http.Client
+	queue         *goconcurrentqueue.FIFO
+	Codec         codec.MessageCodec
+}
+
+// HTTPTgwSimulator is an HTTP server, it queues every incoming request for Receive
+// as a message with Method, Path and Body, and answers with the responses scripted by Send.
+// A response sent holds Status, Headers and Body, and applies to the requests matching
+// its Method and Path, or to every request when they are omitted. It stays until replaced.
+type HTTPTgwSimulator[T fin_codec.BinaryCodec] struct {
+	ListenAddress string
+	server        *http.Server
+	queue         *goconcurrentqueue.FIFO
+	Codec         codec.MessageCodec
+	mu            sync.Mutex
+	responses     map[string]codec.JSONMessage
+}
+
+// asJSONMessage returns message as the JSONMessage built by codec.HTTPMessageCodec
+func asJSONMessage(message fin_codec.BinaryCodec) (codec.JSONMessage, error) {
+	m, ok := message.(*codec.JSONMessage)
+	if !ok || m == nil {
+		return nil, fmt.Errorf("cannot send %T over http", message)
+	}
+	return *m, nil
+}
+
+// httpBody returns the bytes of a body, text is sent as-is and anything else as JSON
+func httpBody(body interface{}) ([]byte, bool, error) {
+	switch v := body.(type) {
+	case nil:
+		return nil, false, nil
+	case string:
+		return []byte(v), false, nil
+	default:
+		data, err := json.Marshal(v)
+		return data, true, err
+	}
+}
+
+// setHTTPHeaders copies a Headers field into header
+func setHTTPHeaders(header http.Header, headers interface{}) error {
+	if headers == nil {
+		return nil
+	}
+	fields, ok := headers.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("headers: cannot convert %T to object", headers)
+	}
+	for k, v := range fields {
+		header.Set(k, fmt.Sprint(v))
+	}
+	return nil
+}
+
+// httpMessage builds the message queued for a request or response
+func httpMessage(fields codec.JSONMessage, body io.Reader) (*codec.JSONMessage, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	if v := codec.DecodeHTTPBody(data); v != nil {
+		fields["Body"] = v
+	}
+	return &fields, nil
+}
+
+// GetCodec returns the message codec used by the simulator
+func (sim *HTTPOmsSimulator[T]) GetCodec() codec.MessageCodec {
+	return sim.Codec
+}
+
+// Start prepares the client, ServerAddress may omit the http:// scheme
+func (sim *HTTPOmsSimulator[T]) Start() error {
+	sim.queue = goconcurrentqueue.NewFIFO()
+	sim.client = &http.Client{Timeout: httpTimeout}
+	if !strings.Contains(sim.ServerAddress, "://") {
+		sim.ServerAddress = "http://" + sim.ServerAddress
+	}
+	log.Printf("HTTP client ready for %s", sim.ServerAddress)
+	return nil
+}
+
+// Send issues the request and queues its response
+func (sim *HTTPOmsSimulator[T]) Send(_ interface{}, message fin_codec.BinaryCodec) error {
+	m, err := asJSONMessage(message)
+	if err != nil {
+		return err
+	}
+	if sim.client == nil {
+		return errors.New("failed to send message: not started")
+	}
+	body, isJSON, err := httpBody(m["Body"])
+	if err != nil {
+		return fmt.Errorf("failed to encode body: %w", err)
+	}
+	method, _ := m["Method"].(string)
+	if method == "" {
+		method = http.MethodGet
+		if body != nil {
+			method = http.MethodPost
+		}
+	}
+	path, _ := m["Path"].(string)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	req, err := http.NewRequest(strings.ToUpper(method), strings.TrimRight(sim.ServerAddress, "/")+path, strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if isJSON {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := setHTTPHeaders(req.Header, m["Headers"]); err != nil {
+		return err
+	}
+	resp, err := sim.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, err := httpMessage(codec.JSONMessage{"Status": resp.StatusCode}, resp.Body)
+	if err != nil {
+		return err
+	}
+	log.Printf("Received response: %v", msg)
+	if err := sim.queue.Enqueue(received{msg: msg}); err != nil {
+		return fmt.Errorf("failed to enqueue message: %w", err)
+	}
+	return nil
+}
+
+// SendFromJSON issues the request described by a JSON-like map
+func (sim *HTTPOmsSimulator[T]) SendFromJSON(message map[string]interface{}) error {
+	m, err := sim.Codec.JSONToStruct(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	return sim.Send(nil, m)
+}
+
+// Receive returns the response of the oldest request not received yet
+func (sim *HTTPOmsSimulator[T]) Receive() (T, error) {
+	return dequeue[T](sim.queue, nil)
+}
+
+// Close releases idle connections
+func (sim *HTTPOmsSimulator[T]) Close() error {
+	if sim.client != nil {
+		sim.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// GetCodec returns the message codec used by the simulator
+func (sim *HTTPTgwSimulator[T]) GetCodec() codec.MessageCodec {
+	return sim.Codec
+}
+
+// Start serves HTTP requests, it blocks until Close is called
+func (sim *HTTPTgwSimulator[T]) Start() error {
+	sim.queue = goconcurrentqueue.NewFIFO()
+	listener, err := net.Listen("tcp", sim.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("error starting server: %w", err)
+	}
+	sim.mu.Lock()
+	if sim.responses == nil {
+		sim.responses = make(map[string]codec.JSONMessage)
+	}
+	sim.server = &http.Server{Handler: http.HandlerFunc(sim.handleRequest)}
+	sim.mu.Unlock()
+	log.Printf("HTTP server started on %s", sim.ListenAddress)
+	err = sim.server.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		log.Println("HTTP server shutting down.")
+		return nil
+	}
+	return err
+}
+
+// responseKey identifies the requests a scripted response applies to
+func responseKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+func (sim *HTTPTgwSimulator[T]) handleRequest(w http.ResponseWriter, r *http.Request) {
+	msg, err := httpMessage(codec.JSONMessage{"Method": r.Method, "Path": r.URL.RequestURI()}, r.Body)
+	if err != nil {
+		log.Printf("Error reading request: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Received request: %v", msg)
+	if e := sim.queue.Enqueue(received{msg: msg}); e != nil {
+		log.Printf("Error enqueuing message: %v", e)
+	}
+	sim.mu.Lock()
+	resp, ok := sim.responses[responseKey(r.Method, r.URL.Path)]
+	if !ok {
+		resp, ok = sim.responses[responseKey("", r.URL.Path)]
+	}
+	if !ok {
+		resp, ok = sim.responses[responseKey("", "")]
+	}
+	sim.mu.Unlock()
+	if !ok {
+		http.Error(w, "no scripted response", http.StatusNotFound)
+		return
+	}
+	status := http.StatusOK
+	if v, ok := resp["Status"]; ok {
+		if status, err = strconv.Atoi(fmt.Sprint(v)); err != nil {
+			http.Error(w, fmt.Sprintf("bad scripted status %v", v), http.StatusInternalServerError)
+			return
+		}
+	}
+	body, isJSON, err := httpBody(resp["Body"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if err := setHTTPHeaders(w.Header(), resp["Headers"]); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// Send scripts the response returned to the requests matching its Method and Path
+func (sim *HTTPTgwSimulator[T]) Send(_ interface{}, message fin_codec.BinaryCodec) error {
+	m, err := asJSONMessage(message)
+	if err != nil {
+		return err
+	}
+	method, _ := m["Method"].(string)
+	path, _ := m["Path"].(string)
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	resp := make(codec.JSONMessage, len(m))
+	for k, v := range m {
+		if k != "Method" && k != "Path" {
+			resp[k] = v
+		}
+	}
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if sim.responses == nil {
+		sim.responses = make(map[string]codec.JSONMessage)
+	}
+	sim.responses[responseKey(method, path)] = resp
+	return nil
+}
+
+// SendFromJSON scripts the response described by a JSON-like map
+func (sim *HTTPTgwSimulator[T]) SendFromJSON(message map[string]interface{}) error {
+	m, err := sim.Codec.JSONToStruct(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	return sim.Send(nil, m)
+}
+
+// Receive returns the oldest request not received yet
+func (sim *HTTPTgwSimulator[T]) Receive() (T, error) {
+	return dequeue[T](sim.queue, nil)
+}
+
+// Close shuts down the server
+func (sim *HTTPTgwSimulator[T]) Close() error {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if sim.server == nil {
+		return nil
+	}
+	return sim.server.Close()
+}
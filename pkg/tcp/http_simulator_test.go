@@ -0,0 +1,67 @@
+package tcp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	gt_codec "github.com/xinchentechnote/gt-auto/pkg/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+func TestHTTPSimulators(t *testing.T) {
+	server, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type:          "tgw",
+		Communication: "http",
+		ListenAddress: "127.0.0.1:19009",
+	})
+	require.NoError(t, err)
+	go server.Start()
+	t.Cleanup(func() { server.Close() })
+	time.Sleep(100 * time.Millisecond)
+
+	client, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type:          "oms",
+		Communication: "http",
+		ServerAddress: "127.0.0.1:19009",
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Start())
+	defer client.Close()
+
+	// scripted response of the kill switch, CSV cells hold the body as JSON text
+	require.NoError(t, server.SendFromJSON(map[string]interface{}{
+		"StepId": "1", "MsgType": "KillSwitchResp",
+		"Method": "POST", "Path": "/risk/kill", "Status": "202", "Body": `{"accepted":true}`,
+	}))
+	require.NoError(t, client.SendFromJSON(map[string]interface{}{
+		"StepId": "2", "MsgType": "KillSwitch",
+		"Path": "/risk/kill", "Headers": `{"X-Operator":"ops"}`, "Account": "A001",
+	}))
+
+	request := receiveWithin(t, server)
+	expect, err := server.GetCodec().JSONToStruct(map[string]interface{}{
+		"MsgType": "KillSwitch", "Method": "POST", "Path": "/risk/kill", "Account": "A001",
+	})
+	require.NoError(t, err)
+	result := validate.CompareStruct(expect, request)
+	assert.True(t, result.Equal, result.Diffs)
+
+	response := receiveWithin(t, client)
+	expect, err = client.GetCodec().JSONToStruct(map[string]interface{}{
+		"MsgType": "KillSwitchResp", "Status": "202", "Body": `{"accepted":true}`,
+	})
+	require.NoError(t, err)
+	result = validate.CompareStruct(expect, response)
+	assert.True(t, result.Equal, result.Diffs)
+
+	// requests without a scripted response are answered 404
+	require.NoError(t, client.SendFromJSON(map[string]interface{}{"Method": "GET", "Path": "/limits"}))
+	receiveWithin(t, server)
+	response = receiveWithin(t, client)
+	assert.Equal(t, 404, (*response.(*gt_codec.JSONMessage))["Status"])
+}
@@ -0,0 +1,168 @@
+package tcp
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/enriquebris/goconcurrentqueue"
+	fin_codec "github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	"github.com/xinchentechnote/gt-auto/pkg/codec"
+)
+
+// ProxySimulator forwards the connections accepted on ListenAddress to ServerAddress frame by frame,
+// decoding both directions and recording every message to CaptureFile when set.
+// Receive returns the forwarded messages of both directions, it cannot send.
+type ProxySimulator[T fin_codec.BinaryCodec] struct {
+	Name          string
+	ListenAddress string
+	ServerAddress string
+	Codec         codec.MessageCodec
+	Framer        codec.Framer
+	CaptureFile   string
+	recorder      *capture.Writer
+	listener      net.Listener
+	stopChan      chan struct{}
+	queue         *goconcurrentqueue.FIFO
+	mu            sync.Mutex
+	sessions      int
+}
+
+// GetCodec returns the message codec used by the simulator
+func (sim *ProxySimulator[T]) GetCodec() codec.MessageCodec {
+	return sim.Codec
+}
+
+// Addr returns the address the proxy listens on, empty until it listens
+func (sim *ProxySimulator[T]) Addr() string {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if sim.listener == nil {
+		return ""
+	}
+	return sim.listener.Addr().String()
+}
+
+// Start accepts connections and forwards them, it blocks until Close is called
+func (sim *ProxySimulator[T]) Start() error {
+	var err error
+	sim.queue = goconcurrentqueue.NewFIFO()
+	if sim.CaptureFile != "" {
+		if sim.recorder, err = capture.Create(sim.CaptureFile); err != nil {
+			return err
+		}
+	}
+	listener, err := net.Listen("tcp", sim.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("error starting proxy: %w", err)
+	}
+	sim.mu.Lock()
+	sim.listener = listener
+	sim.mu.Unlock()
+	sim.stopChan = make(chan struct{})
+	log.Printf("Proxy started on %s for %s", listener.Addr(), sim.ServerAddress)
+	go func() {
+		<-sim.stopChan
+		listener.Close()
+	}()
+	for {
+		client, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				log.Println("Proxy shutting down.")
+				return nil
+			}
+			log.Printf("Accept error: %v", err)
+			continue
+		}
+		go sim.forward(client)
+	}
+}
+
+// forward connects client to the server and pumps frames both ways until either side closes
+func (sim *ProxySimulator[T]) forward(client net.Conn) {
+	server, err := net.DialTimeout("tcp", sim.ServerAddress, 5*time.Second)
+	if err != nil {
+		log.Printf("Proxy failed to connect to %s: %v", sim.ServerAddress, err)
+		client.Close()
+		return
+	}
+	sim.mu.Lock()
+	sim.sessions++
+	session := sim.sessions
+	sim.mu.Unlock()
+	log.Printf("Proxy session %d: %s <-> %s", session, client.RemoteAddr(), sim.ServerAddress)
+	done := make(chan struct{}, 2)
+	go sim.pump(session, capture.FromClient, client, server, done)
+	go sim.pump(session, capture.FromServer, server, client, done)
+	<-done
+	client.Close()
+	server.Close()
+	<-done
+	log.Printf("Proxy session %d closed", session)
+}
+
+// pump copies frames from src to dst, the frame is forwarded untouched even when it cannot be decoded
+func (sim *ProxySimulator[T]) pump(session int, from string, src net.Conn, dst net.Conn, done chan<- struct{}) {
+	defer func() { done <- struct{}{} }()
+	for {
+		data, err := sim.Framer.ReadFrame(src)
+		fault := checksumFault(err)
+		if err != nil && fault == nil {
+			return
+		}
+		if _, err := dst.Write(data); err != nil {
+			log.Printf("Proxy session %d: write error: %v", session, err)
+			return
+		}
+		sim.record(session, from, data, fault)
+	}
+}
+
+func (sim *ProxySimulator[T]) record(session int, from string, data []byte, fault error) {
+	record, msg := capture.Decode(sim.Codec, data, fault)
+	record.Time = time.Now()
+	record.Source = sim.Name
+	record.Session = session
+	record.From = from
+	if msg != nil {
+		if e := sim.queue.Enqueue(received{msg: msg, err: fault}); e != nil {
+			log.Printf("Error enqueuing message: %v", e)
+		}
+	}
+	if sim.recorder != nil {
+		if err := sim.recorder.Write(record); err != nil {
+			log.Printf("Error writing capture: %v", err)
+		}
+	}
+}
+
+// Send is not supported, a proxy only forwards
+func (sim *ProxySimulator[T]) Send(interface{}, fin_codec.BinaryCodec) error {
+	return errors.New("proxy cannot send messages")
+}
+
+// SendFromJSON is not supported, a proxy only forwards
+func (sim *ProxySimulator[T]) SendFromJSON(map[string]interface{}) error {
+	return errors.New("proxy cannot send messages")
+}
+
+// Receive returns the next forwarded message of either direction
+func (sim *ProxySimulator[T]) Receive() (T, error) {
+	return dequeue[T](sim.queue, nil)
+}
+
+// Close stops the proxy and closes the capture file
+func (sim *ProxySimulator[T]) Close() error {
+	if sim.stopChan != nil {
+		close(sim.stopChan)
+	}
+	if sim.recorder != nil {
+		return sim.recorder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package tcp_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/capture"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+func TestProxySimulatorRecords(t *testing.T) {
+	captureFile := filepath.Join(t.TempDir(), "capture.jsonl")
+	tgw, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "tgw", Protocol: "json-lines", ListenAddress: "127.0.0.1:0",
+	})
+	require.NoError(t, err)
+	go tgw.Start()
+	t.Cleanup(func() { tgw.Close() })
+	tgwAddress := listenAddr(t, tgw)
+
+	proxy, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Name: "gw_proxy", Type: "proxy", Protocol: "json-lines",
+		ListenAddress: "127.0.0.1:0", ServerAddress: tgwAddress, CaptureFile: captureFile,
+	})
+	require.NoError(t, err)
+	go proxy.Start()
+	proxyAddress := listenAddr(t, proxy)
+
+	oms, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "oms", Protocol: "json-lines", ServerAddress: proxyAddress,
+	})
+	require.NoError(t, err)
+	require.NoError(t, oms.Start())
+	defer oms.Close()
+
+	order := map[string]interface{}{"MsgType": "Order", "ClOrdID": "1", "Price": "10.5"}
+	require.NoError(t, oms.SendFromJSON(order))
+	assert.True(t, validate.CompareStruct(order, receiveWithin(t, tgw)).Equal)
+	ack := map[string]interface{}{"MsgType": "Ack", "ClOrdID": "1"}
+	require.NoError(t, tgw.SendFromJSON(ack))
+	assert.True(t, validate.CompareStruct(ack, receiveWithin(t, oms)).Equal)
+
+	assert.True(t, validate.CompareStruct(order, receiveWithin(t, proxy)).Equal)
+	assert.True(t, validate.CompareStruct(ack, receiveWithin(t, proxy)).Equal)
+	assert.Error(t, proxy.SendFromJSON(ack))
+	require.NoError(t, proxy.Close())
+
+	records, err := capture.ReadFile(captureFile)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, capture.FromClient, records[0].From)
+	assert.Equal(t, "gw_proxy", records[0].Source)
+	assert.Equal(t, 1, records[0].Session)
+	assert.Equal(t, "Order", records[0].MsgType)
+	assert.True(t, validate.CompareMap(order, records[0].Message).Equal)
+	assert.Equal(t, capture.FromServer, records[1].From)
+	assert.Equal(t, "json-lines", records[1].Protocol)
+	assert.Contains(t, string(records[1].Raw), `"Ack"`)
+	assert.False(t, records[1].Time.Before(records[0].Time))
+}
@@ -0,0 +1,54 @@
+package tcp
+
+import "time"
+
+// SimulatorState is the connection state of a client simulator
+type SimulatorState string
+
+const (
+	// StateConnecting the simulator is dialing, or waiting for the next retry
+	StateConnecting SimulatorState = "connecting"
+	// StateConnected the connection is up
+	StateConnected SimulatorState = "connected"
+	// StateClosed there is no connection and no attempt in progress
+	StateClosed SimulatorState = "closed"
+)
+
+// StateReporter is a simulator exposing its SimulatorState
+type StateReporter interface {
+	State() SimulatorState
+}
+
+const (
+	defaultBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+// ReconnectPolicy controls how a client simulator connects
+// Retries, connection attempts after the first failed one, negative to retry until closed
+// Backoff, delay before the first retry, doubled after every failed retry up to MaxBackoff
+// AutoReconnect, connect again with the same policy when the server closes the connection
+type ReconnectPolicy struct {
+	Retries       int
+	Backoff       time.Duration
+	MaxBackoff    time.Duration
+	AutoReconnect bool
+}
+
+// delay returns the delay before retry n, starting at 0
+func (p ReconnectPolicy) delay(n int) time.Duration {
+	backoff, maxBackoff := p.Backoff, p.MaxBackoff
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	for i := 0; i < n && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
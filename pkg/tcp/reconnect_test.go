@@ -0,0 +1,70 @@
+package tcp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+)
+
+func TestOmsSimulatorReconnect(t *testing.T) {
+	// the oms dials before the tgw listens, so the port is picked beforehand
+	address := freeAddr(t)
+	sim, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "tgw", Protocol: "json-lines", ListenAddress: address,
+	})
+	require.NoError(t, err)
+	tgw := sim.(*tcp.TgwSimulator[codec.BinaryCodec])
+	t.Cleanup(func() { tgw.Close() })
+
+	sim, err = tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "oms", Protocol: "json-lines", ServerAddress: address,
+		Reconnect: config.ReconnectConfig{Retries: 20, BackoffMs: 20, MaxBackoffMs: 50, AutoReconnect: true},
+	})
+	require.NoError(t, err)
+	oms := sim.(*tcp.OmsSimulator[codec.BinaryCodec])
+	defer oms.Close()
+	assert.Equal(t, tcp.StateClosed, oms.State())
+
+	// the gateway starts before the exchange is up
+	started := make(chan error)
+	go func() { started <- oms.Start() }()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, tcp.StateConnecting, oms.State())
+	go tgw.Start()
+	require.NoError(t, <-started)
+	assert.Equal(t, tcp.StateConnected, oms.State())
+	_, err = tgw.Events().Wait(tcp.Connected, "1", time.Time{}, time.Second)
+	require.NoError(t, err)
+
+	// the exchange drops the connection and the gateway comes back
+	mark := time.Now()
+	require.NoError(t, tgw.Disconnect(""))
+	event, err := tgw.Events().Wait(tcp.Connected, "", mark, 2*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 2, event.Session.Index)
+	require.Eventually(t, func() bool { return oms.State() == tcp.StateConnected }, time.Second, 10*time.Millisecond)
+
+	// a disconnect on purpose is not reconnected
+	mark = time.Now()
+	require.NoError(t, oms.Disconnect(""))
+	assert.Equal(t, tcp.StateClosed, oms.State())
+	_, err = tgw.Events().Wait(tcp.Connected, "", mark, 200*time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestOmsSimulatorConnectFails(t *testing.T) {
+	sim, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type: "oms", Protocol: "json-lines", ServerAddress: freeAddr(t),
+		Reconnect: config.ReconnectConfig{Retries: 2, BackoffMs: 10},
+	})
+	require.NoError(t, err)
+	oms := sim.(*tcp.OmsSimulator[codec.BinaryCodec])
+	assert.Error(t, oms.Start())
+	assert.Equal(t, tcp.StateClosed, oms.State())
+	assert.ErrorContains(t, oms.SendFromJSON(map[string]interface{}{"MsgType": "Logon"}), "not connected")
+}
@@ -2,6 +2,7 @@ package tcp
 
 import (
 	"fmt"
+	"time"
 
 	fin_codec "github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
 	"github.com/xinchentechnote/gt-auto/pkg/codec"
@@ -9,29 +10,146 @@ import (
 )
 
 // CreateSimulator creates a simulator based on the provided configuration.
+// The http communication always exchanges JSON, so its protocol is not used.
 func CreateSimulator[T fin_codec.BinaryCodec](config config.SimulatorConfig) (Simulator[T], error) {
-	framer, err := codec.GetDefaultMessageCodecFactory().GetFramer(config.Protocol)
-	if err != nil {
-		return nil, err
+	if config.Communication == "http" {
+		return createHTTPSimulator[T](config)
 	}
-	codec, err := codec.GetDefaultMessageCodecFactory().GetCodec(config.Protocol)
+	framer, codec, err := CreateFramerAndCodec(config)
 	if err != nil {
 		return nil, err
 	}
+	switch config.Communication {
+	case "", "tcp":
+		return createTCPSimulator[T](config, framer, codec)
+	case "ws":
+		return createWsSimulator[T](config, codec)
+	case "udp":
+		return createUDPSimulator[T](config, framer, codec)
+	default:
+		return nil, fmt.Errorf("unknown communication: %s", config.Communication)
+	}
+}
+
+// createTCPSimulator creates a simulator communicating over TCP.
+func createTCPSimulator[T fin_codec.BinaryCodec](config config.SimulatorConfig, framer codec.Framer, codec codec.MessageCodec) (Simulator[T], error) {
 	switch config.Type {
 	case "oms":
-		return &OmsSimulator[T]{
+		sim := &OmsSimulator[T]{
+			ServerAddress: config.ServerAddress,
+			Reconnection: ReconnectPolicy{
+				Retries:       config.Reconnect.Retries,
+				Backoff:       time.Duration(config.Reconnect.BackoffMs) * time.Millisecond,
+				MaxBackoff:    time.Duration(config.Reconnect.MaxBackoffMs) * time.Millisecond,
+				AutoReconnect: config.Reconnect.AutoReconnect,
+			},
+			Codec:  codec,
+			Framer: framer,
+		}
+		sim.SetFaults(config.Faults)
+		return sim, nil
+	case "tgw":
+		sim := &TgwSimulator[T]{
+			ListenAddress:   config.ListenAddress,
+			Codec:           codec,
+			Framer:          framer,
+			CorruptChecksum: config.CorruptChecksum,
+		}
+		sim.SetFaults(config.Faults)
+		return sim, nil
+	case "proxy":
+		return &ProxySimulator[T]{
+			Name:          config.Name,
+			ListenAddress: config.ListenAddress,
 			ServerAddress: config.ServerAddress,
 			Codec:         codec,
 			Framer:        framer,
+			CaptureFile:   config.CaptureFile,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown simulator type: %s", config.Type)
+	}
+}
+
+// createWsSimulator creates a simulator communicating over WebSocket.
+func createWsSimulator[T fin_codec.BinaryCodec](config config.SimulatorConfig, codec codec.MessageCodec) (Simulator[T], error) {
+	switch config.Type {
+	case "oms":
+		return &WsOmsSimulator[T]{
+			ServerAddress: config.ServerAddress,
+			Codec:         codec,
 		}, nil
 	case "tgw":
-		return &TgwSimulator[T]{
+		return &WsTgwSimulator[T]{
 			ListenAddress: config.ListenAddress,
 			Codec:         codec,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown simulator type: %s", config.Type)
+	}
+}
+
+// createUDPSimulator creates a simulator communicating over UDP unicast or multicast.
+func createUDPSimulator[T fin_codec.BinaryCodec](config config.SimulatorConfig, framer codec.Framer, codec codec.MessageCodec) (Simulator[T], error) {
+	switch config.Type {
+	case "oms":
+		return &UDPOmsSimulator[T]{
+			ServerAddress: config.ServerAddress,
+			Codec:         codec,
+			Framer:        framer,
+		}, nil
+	case "tgw":
+		return &UDPTgwSimulator[T]{
+			ListenAddress: config.ListenAddress,
+			Interface:     config.Interface,
+			Codec:         codec,
 			Framer:        framer,
 		}, nil
 	default:
 		return nil, fmt.Errorf("unknown simulator type: %s", config.Type)
 	}
 }
+
+// createHTTPSimulator creates an HTTP client for oms and an HTTP server for tgw.
+func createHTTPSimulator[T fin_codec.BinaryCodec](config config.SimulatorConfig) (Simulator[T], error) {
+	switch config.Type {
+	case "oms":
+		return &HTTPOmsSimulator[T]{
+			ServerAddress: config.ServerAddress,
+			Codec:         &codec.HTTPMessageCodec{},
+		}, nil
+	case "tgw":
+		return &HTTPTgwSimulator[T]{
+			ListenAddress: config.ListenAddress,
+			Codec:         &codec.HTTPMessageCodec{},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown simulator type: %s", config.Type)
+	}
+}
+
+// CreateFramerAndCodec creates the framer and codec of the configured protocol.
+func CreateFramerAndCodec(config config.SimulatorConfig) (codec.Framer, codec.MessageCodec, error) {
+	if config.Protocol == codec.Protobuf {
+		c, err := codec.NewProtobufMessageCodec(codec.ProtobufOptions{
+			ProtoFiles:    config.Protobuf.ProtoFiles,
+			ImportPaths:   config.Protobuf.ImportPaths,
+			DescriptorSet: config.Protobuf.DescriptorSet,
+			Message:       config.Protobuf.Message,
+			LengthPrefix:  config.Protobuf.LengthPrefix,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return c.Framer(), c, nil
+	}
+	framer, err := codec.GetDefaultMessageCodecFactory().GetFramer(config.Protocol)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := codec.GetDefaultMessageCodecFactory().GetCodec(config.Protocol)
+	if err != nil {
+		return nil, nil, err
+	}
+	return framer, c, nil
+}
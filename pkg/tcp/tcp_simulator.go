@@ -1,14 +1,17 @@
 package tcp
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/enriquebris/goconcurrentqueue"
 	fin_codec "github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
 	"github.com/xinchentechnote/gt-auto/pkg/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
 )
 
 // Simulator interface defines the methods for both OMS and TGW simulators
@@ -25,48 +28,186 @@ type Simulator[T fin_codec.BinaryCodec] interface {
 // OmsSimulator simulates the OMS client
 type OmsSimulator[T fin_codec.BinaryCodec] struct {
 	ServerAddress string
+	Reconnection  ReconnectPolicy
+	mu            sync.Mutex
 	conn          net.Conn
+	connections   int
+	state         SimulatorState
+	closed        bool
 	queue         *goconcurrentqueue.FIFO
 	Codec         codec.MessageCodec
 	Framer        codec.Framer
+	events        ConnectionEvents
+	faults        FaultInjector
+	frameTimes
 }
 
 // TgwSimulator simulates the TGW server
+// Every accepted connection is a session with its own queue, see SessionSimulator.
+// Send goes to the session connected last, Receive returns the oldest message of any session.
 type TgwSimulator[T fin_codec.BinaryCodec] struct {
 	ListenAddress string
 	listener      net.Listener
-	stopChan      chan struct{}
-	queue         *goconcurrentqueue.FIFO
-	Codec         codec.MessageCodec
-	Framer        codec.Framer
-	conn          net.Conn
+	// addr is the address bound first, listened on again by StartListener
+	addr     string
+	stopChan chan struct{}
+	Codec    codec.MessageCodec
+	Framer   codec.Framer
+	mu       sync.Mutex
+	sessions []*tgwSession
+	seq      uint64
+	events   ConnectionEvents
+	faults   FaultInjector
+	frameTimes
+	// CorruptChecksum makes every sent frame carry a wrong checksum trailer,
+	// only effective when Framer is a codec.ChecksumFramer
+	CorruptChecksum bool
+}
+
+// received is a decoded message together with the frame fault found while reading it
+// seq orders the messages of different queues, at is when the frame was read
+type received struct {
+	msg interface{}
+	err error
+	seq uint64
+	at  time.Time
+}
+
+// dequeue returns the next received message of queue, marking when its frame was read in times if not nil
+func dequeue[T fin_codec.BinaryCodec](queue *goconcurrentqueue.FIFO, times *frameTimes) (T, error) {
+	msg, err := queue.Dequeue()
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("error dequeuing message: %w", err)
+	}
+	item := msg.(received)
+	if times != nil {
+		times.markReceived(item.at)
+	}
+	return item.msg.(T), item.err
+}
+
+// checksumFault returns err if it is a checksum fault, which is reported with the message
+// instead of dropping the frame
+func checksumFault(err error) error {
+	var checksumErr *codec.ChecksumError
+	if errors.As(err, &checksumErr) {
+		return err
+	}
+	return nil
 }
 
 func (sim *OmsSimulator[T]) GetCodec() codec.MessageCodec {
 	return sim.Codec
 }
 
-// Start connects to the TGWServer
+// Start connects to the TGWServer, retrying as set by Reconnection
 func (sim *OmsSimulator[T]) Start() error {
 	sim.queue = goconcurrentqueue.NewFIFO()
-	var err error
-	sim.conn, err = net.DialTimeout("tcp", sim.ServerAddress, 5*time.Second)
+	sim.mu.Lock()
+	sim.closed = false
+	sim.mu.Unlock()
+	return sim.connectWithRetry()
+}
+
+// State returns the connection state
+func (sim *OmsSimulator[T]) State() SimulatorState {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if sim.state == "" {
+		return StateClosed
+	}
+	return sim.state
+}
+
+func (sim *OmsSimulator[T]) setState(state SimulatorState) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.state = state
+}
+
+// connectWithRetry connects, retrying with backoff until Reconnection.Retries is exhausted or Close is called
+func (sim *OmsSimulator[T]) connectWithRetry() error {
+	sim.setState(StateConnecting)
+	for attempt := 0; ; attempt++ {
+		err := sim.connect()
+		if err == nil {
+			return nil
+		}
+		if sim.Reconnection.Retries >= 0 && attempt >= sim.Reconnection.Retries {
+			sim.setState(StateClosed)
+			return err
+		}
+		delay := sim.Reconnection.delay(attempt)
+		log.Printf("Retrying connection to %s in %s", sim.ServerAddress, delay)
+		time.Sleep(delay)
+		sim.mu.Lock()
+		closed := sim.closed
+		sim.mu.Unlock()
+		if closed {
+			sim.setState(StateClosed)
+			return fmt.Errorf("failed to connect to server: %w", net.ErrClosed)
+		}
+	}
+}
+
+// connect dials the server once and reads from the new connection until it is closed
+func (sim *OmsSimulator[T]) connect() error {
+	conn, err := net.DialTimeout("tcp", sim.ServerAddress, 5*time.Second)
 	if err != nil {
 		log.Printf("failed to connect to server: %s", err)
 		return fmt.Errorf("failed to connect to server: %w", err)
 
 	}
 	log.Printf("Connected to TGW server at %s", sim.ServerAddress)
+	sim.mu.Lock()
+	sim.conn = conn
+	sim.connections++
+	sim.state = StateConnected
+	session := SessionInfo{ID: conn.LocalAddr().String(), Index: sim.connections, Connected: true}
+	sim.mu.Unlock()
+	sim.events.record(Connected, session)
 	go func() {
 		for {
-			if err := sim.receive0(); err != nil {
+			if err := sim.receive0(conn); err != nil {
 				log.Printf("receive0 error: %v", err)
+				if !errors.Is(err, errConnectionClosed) {
+					continue
+				}
+				session.Connected = false
+				sim.events.record(Disconnected, session)
+				sim.connectionLost(conn)
+				return
 			}
 		}
 	}()
 	return nil
 }
 
+// connectionLost handles the end of conn, unless it was closed on purpose
+// it is the remote side closing it, then the simulator reconnects if AutoReconnect is set
+func (sim *OmsSimulator[T]) connectionLost(conn net.Conn) {
+	sim.mu.Lock()
+	if sim.conn != conn {
+		sim.mu.Unlock()
+		return
+	}
+	sim.conn = nil
+	auto := sim.Reconnection.AutoReconnect && !sim.closed
+	sim.state = StateClosed
+	sim.mu.Unlock()
+	log.Printf("Connection to %s closed by server", sim.ServerAddress)
+	if !auto {
+		return
+	}
+	if err := sim.connectWithRetry(); err != nil {
+		log.Printf("Auto reconnect failed: %v", err)
+	}
+}
+
+// errConnectionClosed ends the receive loop of a connection
+var errConnectionClosed = errors.New("connection closed")
+
 // Send sends a message to the server
 func (sim *OmsSimulator[T]) Send(ext interface{}, message fin_codec.BinaryCodec) error {
 	data, e := sim.Codec.Encode(ext, message)
@@ -77,10 +218,17 @@ func (sim *OmsSimulator[T]) Send(ext interface{}, message fin_codec.BinaryCodec)
 }
 
 func (sim *OmsSimulator[T]) sendByte(message []byte) error {
-	_, err := sim.conn.Write(message)
+	sim.mu.Lock()
+	conn := sim.conn
+	sim.mu.Unlock()
+	if conn == nil {
+		return errors.New("failed to send message: not connected")
+	}
+	err := sim.faults.Write(conn, message)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
+	sim.markSent(time.Now())
 	return nil
 }
 
@@ -93,27 +241,30 @@ func (sim *OmsSimulator[T]) SendFromJSON(message map[string]interface{}) error {
 }
 
 // Receive waits for a response from the server
+// A checksum fault on the received frame is returned together with the message.
 func (sim *OmsSimulator[T]) Receive() (T, error) {
-	msg, err := sim.queue.Dequeue()
-	if err != nil {
-		var zero T
-		return zero, fmt.Errorf("error dequeuing message: %w", err)
-	}
-	return msg.(T), nil
+	return dequeue[T](sim.queue, &sim.frameTimes)
 }
 
 // Receive waits for a response from the server
-func (sim *OmsSimulator[T]) receive0() error {
-	data, err := sim.Framer.ReadFrame(sim.conn)
-	if err != nil {
-		return fmt.Errorf("failed to receive message: %w", err)
+// A read error other than a checksum fault means the connection is unusable and returns errConnectionClosed.
+func (sim *OmsSimulator[T]) receive0(conn net.Conn) error {
+	data, err := sim.Framer.ReadFrame(conn)
+	at := time.Now()
+	fault := checksumFault(err)
+	if err != nil && fault == nil {
+		conn.Close()
+		return fmt.Errorf("%w: %w", errConnectionClosed, err)
+	}
+	if fault != nil {
+		log.Printf("Received frame with fault: %v", fault)
 	}
 	_, msg, e := sim.Codec.Decode(data)
 	if e != nil {
-		return fmt.Errorf("failed to decode message: %w", err)
+		return fmt.Errorf("failed to decode message: %w", e)
 	}
 	log.Printf("Received message: %+v", msg)
-	e1 := sim.queue.Enqueue(msg)
+	e1 := sim.queue.Enqueue(received{msg: msg, err: fault, at: at})
 	if e1 != nil {
 		return fmt.Errorf("failed to enqueue message: %w", e1)
 	}
@@ -121,9 +272,49 @@ func (sim *OmsSimulator[T]) receive0() error {
 	return nil
 }
 
-// Close closes the OMSClient connection
+// Disconnect closes the connection, the session argument is ignored
+func (sim *OmsSimulator[T]) Disconnect(string) error {
+	sim.mu.Lock()
+	conn := sim.conn
+	sim.conn = nil
+	sim.state = StateClosed
+	sim.mu.Unlock()
+	if conn == nil {
+		return errors.New("failed to disconnect: not connected")
+	}
+	return conn.Close()
+}
+
+// Reconnect closes the connection if any and connects again, retrying as set by Reconnection
+func (sim *OmsSimulator[T]) Reconnect() error {
+	if err := sim.Disconnect(""); err != nil {
+		log.Printf("Reconnect: %v", err)
+	}
+	return sim.connectWithRetry()
+}
+
+// Events returns the connection events of the simulator
+func (sim *OmsSimulator[T]) Events() *ConnectionEvents {
+	return &sim.events
+}
+
+// SetFaults replaces the faults injected when sending frames
+func (sim *OmsSimulator[T]) SetFaults(faults config.FaultConfig) {
+	sim.faults.Set(faults)
+}
+
+// Close closes the OMSClient connection and stops reconnecting
 func (sim *OmsSimulator[T]) Close() error {
-	return sim.conn.Close()
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.closed = true
+	sim.state = StateClosed
+	if sim.conn == nil {
+		return nil
+	}
+	conn := sim.conn
+	sim.conn = nil
+	return conn.Close()
 }
 
 // GetCodec returns the message codec used by the simulator
@@ -131,55 +322,121 @@ func (sim *TgwSimulator[T]) GetCodec() codec.MessageCodec {
 	return sim.Codec
 }
 
-// Start listens for incoming connections on the TGWServer
+// Start listens for incoming connections on the TGWServer, it blocks until Close is called
 func (sim *TgwSimulator[T]) Start() error {
-	var err error
-	sim.listener, err = net.Listen("tcp", sim.ListenAddress)
+	sim.stopChan = make(chan struct{})
+	if err := sim.StartListener(); err != nil {
+		return err
+	}
+	<-sim.stopChan
+	log.Println("TGW server shutting down.")
+	if err := sim.StopListener(); err != nil {
+		log.Printf("Stop listener: %v", err)
+	}
+	return nil
+}
+
+// StartListener listens on ListenAddress again, after StopListener, on the port bound first when it was 0
+func (sim *TgwSimulator[T]) StartListener() error {
+	sim.mu.Lock()
+	address := sim.addr
+	sim.mu.Unlock()
+	if address == "" {
+		address = sim.ListenAddress
+	}
+	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		return fmt.Errorf("error starting server: %w", err)
 	}
-	log.Printf("TGW server started on %s", sim.ListenAddress)
-	sim.stopChan = make(chan struct{})
-	sim.queue = goconcurrentqueue.NewFIFO()
-	go func() {
-		<-sim.stopChan
-		sim.listener.Close()
-	}()
+	sim.mu.Lock()
+	if sim.listener != nil {
+		sim.mu.Unlock()
+		listener.Close()
+		return errors.New("error starting server: already listening")
+	}
+	sim.listener = listener
+	sim.addr = listener.Addr().String()
+	sim.mu.Unlock()
+	log.Printf("TGW server started on %s", listener.Addr())
+	go sim.accept(listener)
+	return nil
+}
 
+// Addr returns the address the simulator listens on, empty until it listens
+func (sim *TgwSimulator[T]) Addr() string {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if sim.listener == nil {
+		return ""
+	}
+	return sim.addr
+}
+
+// StopListener stops accepting connections, the sessions already accepted are left open
+func (sim *TgwSimulator[T]) StopListener() error {
+	sim.mu.Lock()
+	listener := sim.listener
+	sim.listener = nil
+	sim.mu.Unlock()
+	if listener == nil {
+		return errors.New("error stopping server: not listening")
+	}
+	return listener.Close()
+}
+
+// accept serves the connections of listener until it is closed
+func (sim *TgwSimulator[T]) accept(listener net.Listener) {
 	for {
-		conn, err := sim.listener.Accept()
-		sim.conn = conn
+		conn, err := listener.Accept()
 		if err != nil {
-			select {
-			case <-sim.stopChan:
-				log.Println("TGW server shutting down.")
-				return nil
-			default:
-				log.Printf("Accept error: %v", err)
-				continue
+			if errors.Is(err, net.ErrClosed) {
+				log.Printf("TGW listener on %s closed", sim.ListenAddress)
+				return
 			}
+			log.Printf("Accept error: %v", err)
+			continue
 		}
-		go sim.handleClient(conn)
+		sim.mu.Lock()
+		session := newTgwSession(len(sim.sessions)+1, conn)
+		sim.sessions = append(sim.sessions, session)
+		sim.mu.Unlock()
+		log.Printf("Accepted session %d from %s", session.Index, session.ID)
+		sim.events.record(Connected, session.info())
+		go sim.handleClient(session)
 	}
 }
 
-// Handle incoming client connections and put messages in the queue
-func (sim *TgwSimulator[T]) handleClient(conn net.Conn) {
-	defer conn.Close()
+// Handle incoming client connections and put messages in the session queue
+func (sim *TgwSimulator[T]) handleClient(session *tgwSession) {
+	defer func() {
+		session.conn.Close()
+		session.disconnected()
+		sim.events.record(Disconnected, session.info())
+	}()
 
 	for {
-		data, err := sim.Framer.ReadFrame(conn)
-		if err != nil {
-			log.Printf("Error decoding message: %v", err)
-			continue
+		data, err := sim.Framer.ReadFrame(session.conn)
+		at := time.Now()
+		fault := checksumFault(err)
+		if err != nil && fault == nil {
+			// the stream can not be resynchronized after a failed read
+			log.Printf("Error reading frame, closing session %d: %v", session.Index, err)
+			return
+		}
+		if fault != nil {
+			log.Printf("Received frame with fault: %v", fault)
 		}
 		_, msg, e := sim.Codec.Decode(data)
 		if e != nil {
 			log.Printf("Error decoding message: %v", e)
 			continue
 		}
-		log.Printf("Received message: %+v", msg)
-		e1 := sim.queue.Enqueue(msg)
+		log.Printf("Received message on session %d: %+v", session.Index, msg)
+		session.identify(msg)
+		sim.mu.Lock()
+		sim.seq++
+		e1 := session.queue.Enqueue(received{msg: msg, err: fault, seq: sim.seq, at: at})
+		sim.mu.Unlock()
 		if e1 != nil {
 			log.Printf("Error enqueuing message: %v", e1)
 			continue
@@ -187,39 +444,128 @@ func (sim *TgwSimulator[T]) handleClient(conn net.Conn) {
 	}
 }
 
-// Send sends a message to the client
+// Sessions returns the sessions accepted so far in connection order
+func (sim *TgwSimulator[T]) Sessions() []SessionInfo {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	infos := make([]SessionInfo, 0, len(sim.sessions))
+	for _, s := range sim.sessions {
+		infos = append(infos, s.info())
+	}
+	return infos
+}
+
+// session returns the session matching id, or the connected session accepted last when id is empty
+func (sim *TgwSimulator[T]) session(id string) (*tgwSession, error) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	for i := len(sim.sessions) - 1; i >= 0; i-- {
+		s := sim.sessions[i]
+		if id == "" && s.info().Connected || id != "" && s.matches(id) {
+			return s, nil
+		}
+	}
+	if id == "" {
+		return nil, errors.New("no connected session")
+	}
+	return nil, fmt.Errorf("unknown session: %s", id)
+}
+
+// Send sends a message to the session connected last
 func (sim *TgwSimulator[T]) Send(ext interface{}, message fin_codec.BinaryCodec) error {
+	return sim.SendTo("", ext, message)
+}
+
+// SendTo sends a message to a session
+func (sim *TgwSimulator[T]) SendTo(session string, ext interface{}, message fin_codec.BinaryCodec) error {
 	data, e := sim.Codec.Encode(ext, message)
 	if e != nil {
 		return fmt.Errorf("failed to encode message: %w", e)
 	}
-	return sim.sendByte(data)
+	return sim.sendByte(session, data)
 }
 
-func (sim *TgwSimulator[T]) sendByte(message []byte) error {
-	_, err := sim.conn.Write(message)
+func (sim *TgwSimulator[T]) sendByte(session string, message []byte) error {
+	s, err := sim.session(session)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if sim.CorruptChecksum {
+		if framer, ok := sim.Framer.(codec.ChecksumFramer); ok {
+			framer.CorruptChecksum(message)
+		}
+	}
+	err = sim.faults.Write(s.conn, message)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
+	sim.markSent(time.Now())
 	return nil
 }
 
 func (sim *TgwSimulator[T]) SendFromJSON(message map[string]interface{}) error {
+	return sim.SendFromJSONTo("", message)
+}
+
+// SendFromJSONTo sends a JSON-like map to a session
+func (sim *TgwSimulator[T]) SendFromJSONTo(session string, message map[string]interface{}) error {
 	bytes, e := sim.Codec.EncodeJSONMap(message)
 	if e != nil {
 		return fmt.Errorf("failed to encode message: %w", e)
 	}
-	return sim.sendByte(bytes)
+	return sim.sendByte(session, bytes)
 }
 
-// Receive reads the next message from the queue
+// Receive reads the oldest message of all sessions
+// A checksum fault on the received frame is returned together with the message.
 func (sim *TgwSimulator[T]) Receive() (T, error) {
-	msg, err := sim.queue.Dequeue()
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	var next *tgwSession
+	var nextSeq uint64
+	for _, s := range sim.sessions {
+		head, err := s.queue.Get(0)
+		if err != nil {
+			continue
+		}
+		if seq := head.(received).seq; next == nil || seq < nextSeq {
+			next, nextSeq = s, seq
+		}
+	}
+	if next == nil {
+		var zero T
+		return zero, errors.New("error dequeuing message: empty queue")
+	}
+	return dequeue[T](next.queue, &sim.frameTimes)
+}
+
+// ReceiveFrom reads the next message of a session
+func (sim *TgwSimulator[T]) ReceiveFrom(session string) (T, error) {
+	s, err := sim.session(session)
 	if err != nil {
 		var zero T
-		return zero, fmt.Errorf("error dequeuing message: %w", err)
+		return zero, err
+	}
+	return dequeue[T](s.queue, &sim.frameTimes)
+}
+
+// Disconnect closes a session, the connected session accepted last when session is empty
+func (sim *TgwSimulator[T]) Disconnect(session string) error {
+	s, err := sim.session(session)
+	if err != nil {
+		return fmt.Errorf("failed to disconnect: %w", err)
 	}
-	return msg.(T), nil
+	return s.conn.Close()
+}
+
+// Events returns the connection events of the simulator
+func (sim *TgwSimulator[T]) Events() *ConnectionEvents {
+	return &sim.events
+}
+
+// SetFaults replaces the faults injected when sending frames
+func (sim *TgwSimulator[T]) SetFaults(faults config.FaultConfig) {
+	sim.faults.Set(faults)
 }
 
 // Close shuts down the TGWServer
@@ -0,0 +1,129 @@
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/enriquebris/goconcurrentqueue"
+	fin_codec "github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/codec"
+	"google.golang.org/protobuf/proto"
+)
+
+// SessionSimulator is a Simulator serving several connections at once.
+// A session is selected by its ID, its CompID or its Index.
+type SessionSimulator[T fin_codec.BinaryCodec] interface {
+	Simulator[T]
+	Sessions() []SessionInfo
+	SendTo(session string, ext interface{}, message fin_codec.BinaryCodec) error
+	SendFromJSONTo(session string, message map[string]interface{}) error
+	ReceiveFrom(session string) (T, error)
+}
+
+// SessionInfo describes a connection accepted by a SessionSimulator
+// ID, the remote address
+// CompID, the SenderCompID or PBU of the first message carrying one, usually the logon
+// Index, the connection order starting at 1
+// Connected, false once the connection is closed, its pending messages can still be received
+type SessionInfo struct {
+	ID        string
+	CompID    string
+	Index     int
+	Connected bool
+}
+
+// compIDFields are the fields identifying the sender of a logon, by protocol
+var compIDFields = []string{"SenderCompID", "SenderCompId", "PBU", "Pbu"}
+
+// tgwSession is a connection accepted by TgwSimulator with its own queue
+type tgwSession struct {
+	SessionInfo
+	conn  net.Conn
+	queue *goconcurrentqueue.FIFO
+	// mu guards SessionInfo.CompID and SessionInfo.Connected
+	mu sync.Mutex
+}
+
+func newTgwSession(index int, conn net.Conn) *tgwSession {
+	return &tgwSession{
+		SessionInfo: SessionInfo{ID: conn.RemoteAddr().String(), Index: index, Connected: true},
+		conn:        conn,
+		queue:       goconcurrentqueue.NewFIFO(),
+	}
+}
+
+func (s *tgwSession) info() SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.SessionInfo
+}
+
+func (s *tgwSession) matches(session string) bool {
+	return s.info().Matches(session)
+}
+
+// identify records the CompID carried by msg, the first one wins
+func (s *tgwSession) identify(msg interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.CompID == "" {
+		s.CompID = compID(msg)
+	}
+}
+
+func (s *tgwSession) disconnected() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Connected = false
+}
+
+// compID returns the first of compIDFields set in msg, or "" when there is none
+func compID(msg interface{}) string {
+	switch m := msg.(type) {
+	case *codec.ImixMessage:
+		return lookupCompID(func(name string) interface{} { return m.Fields[name] })
+	case *codec.JSONMessage:
+		return lookupCompID(func(name string) interface{} { return (*m)[name] })
+	case proto.Message:
+		fields := m.ProtoReflect().Descriptor().Fields()
+		return lookupCompID(func(name string) interface{} {
+			if fd := fields.ByJSONName(name); fd != nil {
+				return m.ProtoReflect().Get(fd).Interface()
+			}
+			if fd := fields.ByTextName(name); fd != nil {
+				return m.ProtoReflect().Get(fd).Interface()
+			}
+			return nil
+		})
+	}
+	rv := reflect.ValueOf(msg)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ""
+	}
+	return lookupCompID(func(name string) interface{} {
+		if f := rv.FieldByName(name); f.IsValid() && f.CanInterface() {
+			return f.Interface()
+		}
+		return nil
+	})
+}
+
+func lookupCompID(field func(name string) interface{}) string {
+	for _, name := range compIDFields {
+		if v := field(name); v != nil {
+			if id := strings.TrimSpace(fmt.Sprint(v)); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package tcp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+func TestTgwSimulatorSessions(t *testing.T) {
+	sim, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+		Type:          "tgw",
+		Protocol:      "json-lines",
+		ListenAddress: "127.0.0.1:0",
+	})
+	require.NoError(t, err)
+	go sim.Start()
+	t.Cleanup(func() { sim.Close() })
+	address := listenAddr(t, sim)
+	tgw, ok := sim.(tcp.SessionSimulator[codec.BinaryCodec])
+	require.True(t, ok)
+
+	var gateways []tcp.Simulator[codec.BinaryCodec]
+	for _, compID := range []string{"PBU001", "PBU002"} {
+		oms, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+			Type:          "oms",
+			Protocol:      "json-lines",
+			ServerAddress: address,
+		})
+		require.NoError(t, err)
+		require.NoError(t, oms.Start())
+		defer oms.Close()
+		require.NoError(t, oms.SendFromJSON(map[string]interface{}{"MsgType": "Logon", "SenderCompID": compID}))
+		receiveWithin(t, sim)
+		gateways = append(gateways, oms)
+	}
+
+	sessions := tgw.Sessions()
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "PBU001", sessions[0].CompID)
+	assert.Equal(t, 1, sessions[0].Index)
+	assert.Equal(t, "PBU002", sessions[1].CompID)
+	assert.True(t, sessions[1].Connected)
+
+	// per-session queues keep the messages of each session apart
+	require.NoError(t, gateways[1].SendFromJSON(map[string]interface{}{"MsgType": "Order", "ClOrdID": "2"}))
+	require.NoError(t, gateways[0].SendFromJSON(map[string]interface{}{"MsgType": "Order", "ClOrdID": "1"}))
+	var actual codec.BinaryCodec
+	require.Eventually(t, func() bool {
+		actual, err = tgw.ReceiveFrom("PBU001")
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, validate.CompareStruct(map[string]interface{}{"MsgType": "Order", "ClOrdID": "1"}, actual).Equal)
+	actual = receiveWithin(t, sim)
+	assert.True(t, validate.CompareStruct(map[string]interface{}{"MsgType": "Order", "ClOrdID": "2"}, actual).Equal)
+
+	// Send targets a session by index, the default one is the session connected last
+	require.NoError(t, tgw.SendFromJSONTo("1", map[string]interface{}{"MsgType": "Ack", "ClOrdID": "1"}))
+	require.NoError(t, sim.SendFromJSON(map[string]interface{}{"MsgType": "Ack", "ClOrdID": "2"}))
+	assert.True(t, validate.CompareStruct(map[string]interface{}{"MsgType": "Ack", "ClOrdID": "1"}, receiveWithin(t, gateways[0])).Equal)
+	assert.True(t, validate.CompareStruct(map[string]interface{}{"MsgType": "Ack", "ClOrdID": "2"}, receiveWithin(t, gateways[1])).Equal)
+
+	_, err = tgw.ReceiveFrom("PBU003")
+	assert.ErrorContains(t, err, "unknown session")
+}
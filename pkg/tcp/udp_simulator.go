@@ -0,0 +1,228 @@
+package tcp
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/enriquebris/goconcurrentqueue"
+	fin_codec "github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/codec"
+)
+
+// maxDatagramSize is the largest UDP payload
+const maxDatagramSize = 65535
+
+// UDPOmsSimulator sends datagrams to ServerAddress, which may be a multicast group,
+// and receives the datagrams sent back to its local address.
+// Every datagram carries exactly one frame.
+type UDPOmsSimulator[T fin_codec.BinaryCodec] struct {
+	ServerAddress string
+	server        *net.UDPAddr
+	conn          *net.UDPConn
+	queue         *goconcurrentqueue.FIFO
+	Codec         codec.MessageCodec
+	Framer        codec.Framer
+	frameTimes
+}
+
+// UDPTgwSimulator receives datagrams on ListenAddress.
+// When ListenAddress is a multicast group it joins the group on Interface, or the default interface.
+// Send replies to the peer of the last unicast datagram received.
+type UDPTgwSimulator[T fin_codec.BinaryCodec] struct {
+	ListenAddress string
+	Interface     string
+	conn          *net.UDPConn
+	queue         *goconcurrentqueue.FIFO
+	Codec         codec.MessageCodec
+	Framer        codec.Framer
+	mu            sync.Mutex
+	peer          *net.UDPAddr
+	frameTimes
+}
+
+// readDatagrams decodes every datagram read from conn into queue until conn is closed,
+// onPeer is called with the sender of each datagram when not nil.
+func readDatagrams(conn *net.UDPConn, framer codec.Framer, c codec.MessageCodec, queue *goconcurrentqueue.FIFO, onPeer func(*net.UDPAddr)) {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, peer, err := conn.ReadFromUDP(buf)
+		at := time.Now()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("Error reading datagram: %v", err)
+			continue
+		}
+		if onPeer != nil {
+			onPeer(peer)
+		}
+		data := append([]byte(nil), buf[:n]...)
+		var fault error
+		if checksumFramer, ok := framer.(codec.ChecksumFramer); ok {
+			fault = checksumFault(checksumFramer.VerifyChecksum(data))
+		}
+		if fault != nil {
+			log.Printf("Received frame with fault: %v", fault)
+		}
+		_, msg, e := c.Decode(data)
+		if e != nil {
+			log.Printf("Error decoding message: %v", e)
+			continue
+		}
+		log.Printf("Received message: %+v", msg)
+		if e1 := queue.Enqueue(received{msg: msg, err: fault, at: at}); e1 != nil {
+			log.Printf("Error enqueuing message: %v", e1)
+		}
+	}
+}
+
+// GetCodec returns the message codec used by the simulator
+func (sim *UDPOmsSimulator[T]) GetCodec() codec.MessageCodec {
+	return sim.Codec
+}
+
+// Start opens an unconnected socket, so replies to multicast datagrams
+// coming from the receivers' unicast addresses are accepted too
+func (sim *UDPOmsSimulator[T]) Start() error {
+	sim.queue = goconcurrentqueue.NewFIFO()
+	var err error
+	sim.server, err = net.ResolveUDPAddr("udp", sim.ServerAddress)
+	if err != nil {
+		return fmt.Errorf("failed to resolve server address: %w", err)
+	}
+	sim.conn, err = net.ListenUDP("udp", nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to server: %w", err)
+	}
+	log.Printf("Sending datagrams to %s from %s", sim.ServerAddress, sim.conn.LocalAddr())
+	go readDatagrams(sim.conn, sim.Framer, sim.Codec, sim.queue, nil)
+	return nil
+}
+
+// Send sends a message as one datagram
+func (sim *UDPOmsSimulator[T]) Send(ext interface{}, message fin_codec.BinaryCodec) error {
+	data, e := sim.Codec.Encode(ext, message)
+	if e != nil {
+		return fmt.Errorf("failed to encode message: %w", e)
+	}
+	return sim.sendByte(data)
+}
+
+// SendFromJSON sends a JSON-like map as one datagram
+func (sim *UDPOmsSimulator[T]) SendFromJSON(message map[string]interface{}) error {
+	data, e := sim.Codec.EncodeJSONMap(message)
+	if e != nil {
+		return fmt.Errorf("failed to encode message: %w", e)
+	}
+	return sim.sendByte(data)
+}
+
+func (sim *UDPOmsSimulator[T]) sendByte(data []byte) error {
+	if sim.conn == nil {
+		return errors.New("failed to send message: not started")
+	}
+	if _, err := sim.conn.WriteToUDP(data, sim.server); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	sim.markSent(time.Now())
+	return nil
+}
+
+// Receive reads the next message from the queue
+func (sim *UDPOmsSimulator[T]) Receive() (T, error) {
+	return dequeue[T](sim.queue, &sim.frameTimes)
+}
+
+// Close closes the socket
+func (sim *UDPOmsSimulator[T]) Close() error {
+	if sim.conn == nil {
+		return nil
+	}
+	return sim.conn.Close()
+}
+
+// GetCodec returns the message codec used by the simulator
+func (sim *UDPTgwSimulator[T]) GetCodec() codec.MessageCodec {
+	return sim.Codec
+}
+
+// Start binds ListenAddress, joining the multicast group if it is one
+func (sim *UDPTgwSimulator[T]) Start() error {
+	sim.queue = goconcurrentqueue.NewFIFO()
+	addr, err := net.ResolveUDPAddr("udp", sim.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to resolve listen address: %w", err)
+	}
+	if addr.IP != nil && addr.IP.IsMulticast() {
+		var ifi *net.Interface
+		if sim.Interface != "" {
+			ifi, err = net.InterfaceByName(sim.Interface)
+			if err != nil {
+				return fmt.Errorf("unknown interface %s: %w", sim.Interface, err)
+			}
+		}
+		sim.conn, err = net.ListenMulticastUDP("udp", ifi, addr)
+	} else {
+		sim.conn, err = net.ListenUDP("udp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("error starting server: %w", err)
+	}
+	log.Printf("UDP server started on %s", sim.ListenAddress)
+	go readDatagrams(sim.conn, sim.Framer, sim.Codec, sim.queue, func(peer *net.UDPAddr) {
+		sim.mu.Lock()
+		sim.peer = peer
+		sim.mu.Unlock()
+	})
+	return nil
+}
+
+// Send sends a message to the last peer
+func (sim *UDPTgwSimulator[T]) Send(ext interface{}, message fin_codec.BinaryCodec) error {
+	data, e := sim.Codec.Encode(ext, message)
+	if e != nil {
+		return fmt.Errorf("failed to encode message: %w", e)
+	}
+	return sim.sendByte(data)
+}
+
+// SendFromJSON sends a JSON-like map to the last peer
+func (sim *UDPTgwSimulator[T]) SendFromJSON(message map[string]interface{}) error {
+	data, e := sim.Codec.EncodeJSONMap(message)
+	if e != nil {
+		return fmt.Errorf("failed to encode message: %w", e)
+	}
+	return sim.sendByte(data)
+}
+
+func (sim *UDPTgwSimulator[T]) sendByte(data []byte) error {
+	sim.mu.Lock()
+	peer := sim.peer
+	sim.mu.Unlock()
+	if sim.conn == nil || peer == nil {
+		return errors.New("failed to send message: no peer to reply to")
+	}
+	if _, err := sim.conn.WriteToUDP(data, peer); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	sim.markSent(time.Now())
+	return nil
+}
+
+// Receive reads the next message from the queue
+func (sim *UDPTgwSimulator[T]) Receive() (T, error) {
+	return dequeue[T](sim.queue, &sim.frameTimes)
+}
+
+// Close closes the socket, leaving the multicast group
+func (sim *UDPTgwSimulator[T]) Close() error {
+	if sim.conn == nil {
+		return nil
+	}
+	return sim.conn.Close()
+}
@@ -0,0 +1,70 @@
+package tcp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+func TestUDPSimulators(t *testing.T) {
+	tests := map[string]struct {
+		listen string
+		server string
+	}{
+		"unicast":   {listen: "127.0.0.1:19007", server: "127.0.0.1:19007"},
+		"multicast": {listen: "239.1.1.7:19008", server: "239.1.1.7:19008"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tgw, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+				Type:          "tgw",
+				Communication: "udp",
+				Protocol:      "json-lines",
+				ListenAddress: tt.listen,
+			})
+			require.NoError(t, err)
+			if err := tgw.Start(); err != nil {
+				t.Skipf("udp %s not available: %v", name, err)
+			}
+			defer tgw.Close()
+
+			oms, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+				Type:          "oms",
+				Communication: "udp",
+				Protocol:      "json-lines",
+				ServerAddress: tt.server,
+			})
+			require.NoError(t, err)
+			require.NoError(t, oms.Start())
+			defer oms.Close()
+
+			quote := map[string]interface{}{"MsgType": "Quote", "SecurityID": "000001", "Price": "100"}
+			require.NoError(t, oms.SendFromJSON(quote))
+			var actual codec.BinaryCodec
+			for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+				if actual, err = tgw.Receive(); err == nil {
+					break
+				}
+				if time.Now().After(deadline) {
+					// multicast may not be routed in sandboxed networks
+					t.Skipf("no %s datagram delivered", name)
+				}
+			}
+			result := validate.CompareStruct(quote, actual)
+			assert.True(t, result.Equal, result.Diffs)
+
+			require.NoError(t, tgw.SendFromJSON(map[string]interface{}{"MsgType": "Ack"}))
+			require.Eventually(t, func() bool {
+				actual, err = oms.Receive()
+				return err == nil
+			}, time.Second, 10*time.Millisecond)
+			assert.True(t, validate.CompareStruct(map[string]interface{}{"MsgType": "Ack"}, actual).Equal)
+		})
+	}
+}
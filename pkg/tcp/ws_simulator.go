@@ -0,0 +1,231 @@
+package tcp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enriquebris/goconcurrentqueue"
+	"github.com/gorilla/websocket"
+	fin_codec "github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/codec"
+)
+
+// WsOmsSimulator simulates an OMS client connecting over WebSocket.
+// Every WebSocket message carries exactly one encoded message, so no Framer is needed.
+type WsOmsSimulator[T fin_codec.BinaryCodec] struct {
+	ServerAddress string
+	conn          *websocket.Conn
+	queue         *goconcurrentqueue.FIFO
+	Codec         codec.MessageCodec
+	frameTimes
+}
+
+// WsTgwSimulator simulates a server accepting WebSocket connections on any path.
+type WsTgwSimulator[T fin_codec.BinaryCodec] struct {
+	ListenAddress string
+	server        *http.Server
+	queue         *goconcurrentqueue.FIFO
+	Codec         codec.MessageCodec
+	mu            sync.Mutex
+	conn          *websocket.Conn
+	frameTimes
+}
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// wsMessageType sends text protocols as text messages and everything else as binary
+func wsMessageType(c codec.MessageCodec) int {
+	if c.ProtoName() == codec.JSONLines {
+		return websocket.TextMessage
+	}
+	return websocket.BinaryMessage
+}
+
+// writeWsMessage writes one encoded message, the line terminator of text protocols is dropped
+func writeWsMessage(conn *websocket.Conn, c codec.MessageCodec, data []byte) error {
+	if conn == nil {
+		return errors.New("failed to send message: not connected")
+	}
+	messageType := wsMessageType(c)
+	if messageType == websocket.TextMessage {
+		data = bytes.TrimRight(data, "\r\n")
+	}
+	if err := conn.WriteMessage(messageType, data); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// readWsMessages decodes every incoming message into queue until the connection is closed
+func readWsMessages(conn *websocket.Conn, c codec.MessageCodec, queue *goconcurrentqueue.FIFO) {
+	for {
+		_, data, err := conn.ReadMessage()
+		at := time.Now()
+		if err != nil {
+			log.Printf("WebSocket connection closed: %v", err)
+			return
+		}
+		_, msg, e := c.Decode(data)
+		if e != nil {
+			log.Printf("Error decoding message: %v", e)
+			continue
+		}
+		log.Printf("Received message: %+v", msg)
+		if e1 := queue.Enqueue(received{msg: msg, at: at}); e1 != nil {
+			log.Printf("Error enqueuing message: %v", e1)
+		}
+	}
+}
+
+// GetCodec returns the message codec used by the simulator
+func (sim *WsOmsSimulator[T]) GetCodec() codec.MessageCodec {
+	return sim.Codec
+}
+
+// Start connects to the server, ServerAddress may omit the ws:// scheme
+func (sim *WsOmsSimulator[T]) Start() error {
+	sim.queue = goconcurrentqueue.NewFIFO()
+	url := sim.ServerAddress
+	if !strings.Contains(url, "://") {
+		url = "ws://" + url
+	}
+	var err error
+	sim.conn, _, err = websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		log.Printf("failed to connect to server: %s", err)
+		return fmt.Errorf("failed to connect to server: %w", err)
+	}
+	log.Printf("Connected to WebSocket server at %s", url)
+	go readWsMessages(sim.conn, sim.Codec, sim.queue)
+	return nil
+}
+
+// Send sends a message to the server
+func (sim *WsOmsSimulator[T]) Send(ext interface{}, message fin_codec.BinaryCodec) error {
+	data, e := sim.Codec.Encode(ext, message)
+	if e != nil {
+		return fmt.Errorf("failed to encode message: %w", e)
+	}
+	if err := writeWsMessage(sim.conn, sim.Codec, data); err != nil {
+		return err
+	}
+	sim.markSent(time.Now())
+	return nil
+}
+
+// SendFromJSON sends a JSON-like map to the server
+func (sim *WsOmsSimulator[T]) SendFromJSON(message map[string]interface{}) error {
+	data, e := sim.Codec.EncodeJSONMap(message)
+	if e != nil {
+		return fmt.Errorf("failed to encode message: %w", e)
+	}
+	if err := writeWsMessage(sim.conn, sim.Codec, data); err != nil {
+		return err
+	}
+	sim.markSent(time.Now())
+	return nil
+}
+
+// Receive waits for a message from the server
+func (sim *WsOmsSimulator[T]) Receive() (T, error) {
+	return dequeue[T](sim.queue, &sim.frameTimes)
+}
+
+// Close closes the connection
+func (sim *WsOmsSimulator[T]) Close() error {
+	if sim.conn == nil {
+		return nil
+	}
+	return sim.conn.Close()
+}
+
+// GetCodec returns the message codec used by the simulator
+func (sim *WsTgwSimulator[T]) GetCodec() codec.MessageCodec {
+	return sim.Codec
+}
+
+// Start serves WebSocket connections, it blocks until Close is called.
+// Send goes to the connection accepted last.
+func (sim *WsTgwSimulator[T]) Start() error {
+	sim.queue = goconcurrentqueue.NewFIFO()
+	listener, err := net.Listen("tcp", sim.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("error starting server: %w", err)
+	}
+	sim.mu.Lock()
+	sim.server = &http.Server{Handler: http.HandlerFunc(sim.handleUpgrade)}
+	sim.mu.Unlock()
+	log.Printf("WebSocket server started on %s", sim.ListenAddress)
+	err = sim.server.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		log.Println("WebSocket server shutting down.")
+		return nil
+	}
+	return err
+}
+
+func (sim *WsTgwSimulator[T]) handleUpgrade(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Upgrade error: %v", err)
+		return
+	}
+	sim.mu.Lock()
+	sim.conn = conn
+	sim.mu.Unlock()
+	defer conn.Close()
+	readWsMessages(conn, sim.Codec, sim.queue)
+}
+
+// Send sends a message to the client
+func (sim *WsTgwSimulator[T]) Send(ext interface{}, message fin_codec.BinaryCodec) error {
+	data, e := sim.Codec.Encode(ext, message)
+	if e != nil {
+		return fmt.Errorf("failed to encode message: %w", e)
+	}
+	return sim.sendByte(data)
+}
+
+// SendFromJSON sends a JSON-like map to the client
+func (sim *WsTgwSimulator[T]) SendFromJSON(message map[string]interface{}) error {
+	data, e := sim.Codec.EncodeJSONMap(message)
+	if e != nil {
+		return fmt.Errorf("failed to encode message: %w", e)
+	}
+	return sim.sendByte(data)
+}
+
+func (sim *WsTgwSimulator[T]) sendByte(data []byte) error {
+	sim.mu.Lock()
+	conn := sim.conn
+	sim.mu.Unlock()
+	if err := writeWsMessage(conn, sim.Codec, data); err != nil {
+		return err
+	}
+	sim.markSent(time.Now())
+	return nil
+}
+
+// Receive reads the next message from the queue
+func (sim *WsTgwSimulator[T]) Receive() (T, error) {
+	return dequeue[T](sim.queue, &sim.frameTimes)
+}
+
+// Close shuts down the server
+func (sim *WsTgwSimulator[T]) Close() error {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if sim.server == nil {
+		return nil
+	}
+	return sim.server.Close()
+}
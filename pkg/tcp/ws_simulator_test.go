@@ -0,0 +1,86 @@
+package tcp_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xinchentechnote/fin-proto-runtime-bin-go/codec"
+	"github.com/xinchentechnote/gt-auto/pkg/config"
+	"github.com/xinchentechnote/gt-auto/pkg/tcp"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+func receiveWithin[T codec.BinaryCodec](t *testing.T, sim tcp.Simulator[T]) T {
+	var msg T
+	var err error
+	require.Eventually(t, func() bool {
+		msg, err = sim.Receive()
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	return msg
+}
+
+// listenAddr waits for a server simulator configured on port 0 to listen and returns the address it bound
+func listenAddr(t *testing.T, sim interface{}) string {
+	reporter, ok := sim.(tcp.AddrReporter)
+	require.True(t, ok)
+	require.Eventually(t, func() bool { return reporter.Addr() != "" }, time.Second, 10*time.Millisecond)
+	return reporter.Addr()
+}
+
+// freeAddr returns a local address nothing listens on, for a client dialing before its server listens
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestJSONLinesOverTCPAndWs(t *testing.T) {
+	for communication, address := range map[string]string{"tcp": "127.0.0.1:19005", "ws": "127.0.0.1:19006"} {
+		t.Run(communication, func(t *testing.T) {
+			tgw, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+				Type:          "tgw",
+				Communication: communication,
+				Protocol:      "json-lines",
+				ListenAddress: address,
+			})
+			require.NoError(t, err)
+			go tgw.Start()
+			t.Cleanup(func() { tgw.Close() })
+			time.Sleep(100 * time.Millisecond)
+
+			oms, err := tcp.CreateSimulator[codec.BinaryCodec](config.SimulatorConfig{
+				Type:          "oms",
+				Communication: communication,
+				Protocol:      "json-lines",
+				ServerAddress: address,
+			})
+			require.NoError(t, err)
+			require.NoError(t, oms.Start())
+			defer oms.Close()
+
+			order := map[string]interface{}{
+				"StepId":  "new_order_001",
+				"MsgType": "NewOrder",
+				"ClOrdID": "c0001",
+				"Price":   "100",
+				"Legs":    `[{"Side":"1"}]`,
+			}
+			require.NoError(t, oms.SendFromJSON(order))
+			actual := receiveWithin(t, tgw)
+			expect, err := tgw.GetCodec().JSONToStruct(order)
+			require.NoError(t, err)
+			result := validate.CompareStruct(expect, actual)
+			assert.True(t, result.Equal, result.Diffs)
+
+			require.NoError(t, tgw.SendFromJSON(map[string]interface{}{"MsgType": "Confirm", "OrdStatus": 0}))
+			actual = receiveWithin(t, oms)
+			result = validate.CompareStruct(map[string]interface{}{"MsgType": "Confirm", "OrdStatus": "0"}, actual)
+			assert.True(t, result.Equal, result.Diffs)
+		})
+	}
+}
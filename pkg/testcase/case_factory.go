@@ -1,6 +1,7 @@
 package testcase
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -8,21 +9,41 @@ import (
 
 // LoadTestCases load test cases by file path
 // It's just support csv now
+// A dropped step fails the load, so that it cannot let its case pass,
+// the other problems the parser worked around are only reported by CheckTestCases
 func LoadTestCases(filePath string) ([]*TestCase, error) {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	var parser CaseParser
+	cases, warnings, err := CheckTestCases(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var dropped []error
+	for _, w := range warnings {
+		var stepErr *DroppedStepError
+		if errors.As(w, &stepErr) {
+			dropped = append(dropped, w)
+		}
+	}
+	if len(dropped) > 0 {
+		return nil, fmt.Errorf("%s: %d steps dropped, run lint for details: %w", filePath, len(dropped), errors.Join(dropped...))
+	}
+	return cases, nil
+}
 
+// CheckTestCases loads test cases like LoadTestCases, it also returns the problems the parser worked around,
+// such as steps dropped because their test data could not be found
+func CheckTestCases(filePath string) ([]*TestCase, []error, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
 	switch ext {
 	case ".csv":
-		parser = &CSVCaseParser{FilePath: filePath}
+		parser := &CSVCaseParser{FilePath: filePath}
+		cases, err := parser.Parse()
+		return cases, parser.Warnings, err
 	// TODO
 	// case ".json":
 	// 	parser = &JSONCaseParser{FilePath: filePath}
 	// case ".xls", ".xlsx":
 	// 	parser = &ExcelCaseParser{FilePath: filePath}
 	default:
-		return nil, fmt.Errorf("unsupported file extension: %s", ext)
+		return nil, nil, fmt.Errorf("unsupported file extension: %s", ext)
 	}
-
-	return parser.Parse()
 }
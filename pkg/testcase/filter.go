@@ -0,0 +1,117 @@
+package testcase
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Filter selects the cases of a suite to run, the setup and teardown sections are always kept
+// IncludeTags, only the cases with one of these tags run, all of them when empty
+// ExcludeTags, the cases with one of these tags are skipped
+// CaseID, a glob matching the IDs of the cases to run, or a regular expression between slashes
+// FromStep, the run starts at this step: the selected cases before its case and the steps before it are skipped,
+// given as case_id:step_id, or as a step_id found in a single selected case
+type Filter struct {
+	IncludeTags []string
+	ExcludeTags []string
+	CaseID      string
+	FromStep    string
+}
+
+// Apply returns the cases selected by the filter, in their order
+func (f Filter) Apply(cases []*TestCase) ([]*TestCase, error) {
+	matchID, err := f.idMatcher()
+	if err != nil {
+		return nil, err
+	}
+	var selected []*TestCase
+	for _, c := range cases {
+		if c.IsSection() {
+			selected = append(selected, c)
+			continue
+		}
+		if !matchID(c.CaseID) || !f.tagsMatch(c.Tags) {
+			continue
+		}
+		selected = append(selected, c)
+	}
+	if f.FromStep == "" {
+		return selected, nil
+	}
+	caseID, stepID := "", f.FromStep
+	if i := strings.LastIndex(f.FromStep, ":"); i >= 0 {
+		caseID, stepID = f.FromStep[:i], f.FromStep[i+1:]
+	}
+	from, at := -1, 0
+	for i, c := range selected {
+		if c.IsSection() || (caseID != "" && c.CaseID != caseID) {
+			continue
+		}
+		for j, step := range c.Steps {
+			if step.StepID != stepID {
+				continue
+			}
+			if from >= 0 {
+				return nil, fmt.Errorf("step %q is in cases %s and %s, use case_id:step_id", stepID, selected[from].CaseID, c.CaseID)
+			}
+			from, at = i, j
+			break
+		}
+	}
+	if from < 0 {
+		return nil, fmt.Errorf("no step %q in the selected cases", f.FromStep)
+	}
+	selected[from].Steps = selected[from].Steps[at:]
+	return append(sectionsOf(selected[:from]), selected[from:]...), nil
+}
+
+func sectionsOf(cases []*TestCase) []*TestCase {
+	var sections []*TestCase
+	for _, c := range cases {
+		if c.IsSection() {
+			sections = append(sections, c)
+		}
+	}
+	return sections
+}
+
+func (f Filter) idMatcher() (func(string) bool, error) {
+	pattern := f.CaseID
+	switch {
+	case pattern == "":
+		return func(string) bool { return true }, nil
+	case len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/"):
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid case ID pattern: %w", err)
+		}
+		return re.MatchString, nil
+	default:
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid case ID pattern: %w", err)
+		}
+		return func(id string) bool {
+			matched, _ := path.Match(pattern, id)
+			return matched
+		}, nil
+	}
+}
+
+func (f Filter) tagsMatch(tags []string) bool {
+	has := func(wanted []string) bool {
+		for _, w := range wanted {
+			for _, t := range tags {
+				if strings.EqualFold(w, t) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if has(f.ExcludeTags) {
+		return false
+	}
+	return len(f.IncludeTags) == 0 || has(f.IncludeTags)
+}
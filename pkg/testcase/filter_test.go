@@ -0,0 +1,78 @@
+package testcase
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadTagged(t *testing.T) []*TestCase {
+	cases, err := LoadTestCases(filepath.Join("testdata", "tagged_test_case.csv"))
+	assert.NoError(t, err)
+	assert.Len(t, cases, 3)
+	return cases
+}
+
+func caseIDs(cases []*TestCase) []string {
+	ids := make([]string, 0, len(cases))
+	for _, c := range cases {
+		ids = append(ids, c.CaseID)
+	}
+	return ids
+}
+
+func TestParseTags(t *testing.T) {
+	cases := loadTagged(t)
+	assert.Equal(t, []string{"smoke", "order"}, cases[0].Tags)
+	assert.Equal(t, []string{"order", "slow"}, cases[1].Tags)
+	assert.Empty(t, cases[2].Tags)
+}
+
+func TestFilterApply(t *testing.T) {
+	for name, tc := range map[string]struct {
+		filter   Filter
+		expected []string
+	}{
+		"all":          {Filter{}, []string{"szse_001", "szse_002", "failover_001"}},
+		"include":      {Filter{IncludeTags: []string{"SMOKE"}}, []string{"szse_001"}},
+		"exclude":      {Filter{ExcludeTags: []string{"slow"}}, []string{"szse_001", "failover_001"}},
+		"both":         {Filter{IncludeTags: []string{"order"}, ExcludeTags: []string{"slow"}}, []string{"szse_001"}},
+		"glob":         {Filter{CaseID: "szse_*"}, []string{"szse_001", "szse_002"}},
+		"regex":        {Filter{CaseID: "/^(failover|szse)_00[12]$/"}, []string{"szse_001", "szse_002", "failover_001"}},
+		"regex middle": {Filter{CaseID: "/over/"}, []string{"failover_001"}},
+	} {
+		selected, err := tc.filter.Apply(loadTagged(t))
+		assert.NoError(t, err, name)
+		assert.Equal(t, tc.expected, caseIDs(selected), name)
+	}
+}
+
+func TestFilterFromStep(t *testing.T) {
+	selected, err := Filter{CaseID: "szse_*", FromStep: "szse_001:new_order_002"}.Apply(loadTagged(t))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"szse_001", "szse_002"}, caseIDs(selected))
+	assert.Len(t, selected[0].Steps, 1)
+	assert.Equal(t, "new_order_002", selected[0].Steps[0].StepID)
+	assert.Len(t, selected[1].Steps, 2)
+
+	selected, err = Filter{FromStep: "drop_001"}.Apply(loadTagged(t))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"failover_001"}, caseIDs(selected))
+
+	selected, err = Filter{CaseID: "szse_002", FromStep: "new_order_002"}.Apply(loadTagged(t))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"szse_002"}, caseIDs(selected))
+	assert.Len(t, selected[0].Steps, 1)
+
+	_, err = Filter{FromStep: "new_order_002"}.Apply(loadTagged(t))
+	assert.ErrorContains(t, err, "use case_id:step_id")
+	_, err = Filter{FromStep: "missing"}.Apply(loadTagged(t))
+	assert.Error(t, err)
+	_, err = Filter{FromStep: "szse_003:new_order_002"}.Apply(loadTagged(t))
+	assert.Error(t, err)
+	_, err = Filter{CaseID: "/(/"}.Apply(loadTagged(t))
+	assert.Error(t, err)
+	_, err = Filter{CaseID: "["}.Apply(loadTagged(t))
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package testcase
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParamTable is a parameter sheet, each row expands a case into one concrete case
+type ParamTable struct {
+	Names []string
+	Rows  [][]string
+}
+
+// LoadParamTable loads a parameter sheet, its header names the parameters
+func LoadParamTable(filePath string) (*ParamTable, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%s: parameter table has no rows", filePath)
+	}
+	table := &ParamTable{Names: records[0]}
+	for _, row := range records[1:] {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+// Expand returns one copy of the case per row of the table, with an ID listing the parameters of
+// the row, e.g. szse_001[SecurityID=000001,Side=2], and every ${name} of a parameter in its test data
+// replaced by the value of the row.
+func (p *ParamTable) Expand(c *TestCase) []*TestCase {
+	expanded := make([]*TestCase, 0, len(p.Rows))
+	for _, row := range p.Rows {
+		params := make(map[string]string, len(p.Names))
+		pairs := make([]string, len(p.Names))
+		for i, name := range p.Names {
+			params[name] = row[i]
+			pairs[i] = name + "=" + row[i]
+		}
+		concrete := c.Clone()
+		concrete.CaseID = fmt.Sprintf("%s[%s]", c.CaseID, strings.Join(pairs, ","))
+		concrete.Params = params
+		for i := range concrete.Steps {
+			concrete.Steps[i].TestDatas = SubstituteVariables(concrete.Steps[i].TestDatas, params)
+		}
+		expanded = append(expanded, concrete)
+	}
+	return expanded
+}
@@ -0,0 +1,27 @@
+package testcase
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVCaseParserExpandParams(t *testing.T) {
+	cases, err := LoadTestCases(filepath.Join("testdata", "param_test_case.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"szse_001[SecurityID=000001,Side=1]", "szse_001[SecurityID=000002,Side=2]", "szse_002"}, caseIDs(cases))
+
+	second := cases[1]
+	assert.Equal(t, map[string]string{"SecurityID": "000002", "Side": "2"}, second.Params)
+	assert.Equal(t, []string{"order"}, second.Tags)
+	assert.Equal(t, "000002", second.Steps[0].TestDatas["SecurityID"])
+	assert.Equal(t, "c0000022", second.Steps[0].TestDatas["ClOrdID"])
+	assert.Equal(t, "${ClOrdID}", second.Steps[1].TestDatas["ClOrdID"], "captures are left to the run")
+	assert.Equal(t, "000001", cases[0].Steps[1].TestDatas["SecurityID"], "each case gets its own data")
+}
+
+func TestLoadParamTableMissing(t *testing.T) {
+	_, err := LoadParamTable(filepath.Join("testdata", "no_such_params.csv"))
+	assert.Error(t, err)
+}
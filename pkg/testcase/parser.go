@@ -2,6 +2,7 @@ package testcase
 
 import (
 	"encoding/csv"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -10,10 +11,45 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// tagsColumn is the optional column after test_data listing the tags of a case,
+// separated by spaces or semicolons
+const tagsColumn = 10
+
+// paramsColumn is the optional column after tags naming the parameter sheet of a case,
+// the case is expanded into one case per row of the sheet, see ParamTable
+const paramsColumn = 11
+
+// onFailureColumn is the optional column after params with the failure policy of a step
+const onFailureColumn = 12
+
+// testDataColumn is the last column every step row needs
+const testDataColumn = 9
+
+// DroppedStepError is the warning for a step left out of its case because its test data
+// could not be found, the case would run without it.
+type DroppedStepError struct {
+	CaseID string
+	StepID string
+	Err    error
+}
+
+// Error implements error.
+func (e *DroppedStepError) Error() string {
+	return fmt.Sprintf("case %s step %s dropped: %v", e.CaseID, e.StepID, e.Err)
+}
+
+// Unwrap returns the reason the step was dropped.
+func (e *DroppedStepError) Unwrap() error {
+	return e.Err
+}
+
 // CSVCaseParser implements the CaseParser interface for CSV files.
+// Warnings are the problems Parse worked around: rows dropped because they are too short
+// or their test data could not be found, and test data sheets with duplicate columns.
 type CSVCaseParser struct {
 	FilePath      string
-	testDataCache map[string]map[string]interface{}
+	Warnings      []error
+	testDataCache map[string]map[string]map[string]interface{}
 }
 
 // Parse parses CSV data and returns test cases.
@@ -32,6 +68,7 @@ func (p *CSVCaseParser) Parse() ([]*TestCase, error) {
 
 	var cases []*TestCase
 	var currentCase *TestCase
+	paramSheets := make(map[*TestCase]string)
 
 	for {
 		record, err := reader.Read()
@@ -42,12 +79,22 @@ func (p *CSVCaseParser) Parse() ([]*TestCase, error) {
 			return nil, err
 		}
 
+		if len(record) <= testDataColumn {
+			p.warnf("%s: row %v has %d columns, want at least %d", p.FilePath, record, len(record), testDataColumn+1)
+			continue
+		}
 		if strings.TrimSpace(record[0]) != "" {
 			currentCase = &TestCase{
 				CaseID:    record[0],
 				CaseTitle: record[1],
 				Steps:     []TestStep{},
 			}
+			if len(record) > tagsColumn {
+				currentCase.Tags = parseTags(record[tagsColumn])
+			}
+			if len(record) > paramsColumn && strings.TrimSpace(record[paramsColumn]) != "" {
+				paramSheets[currentCase] = strings.TrimSpace(record[paramsColumn])
+			}
 			cases = append(cases, currentCase)
 		}
 
@@ -65,34 +112,120 @@ func (p *CSVCaseParser) Parse() ([]*TestCase, error) {
 			MsgType:        record[8],
 			TestData:       record[9],
 		}
+		if len(record) > onFailureColumn {
+			if step.OnFailure, err = parseOnFailure(record[onFailureColumn]); err != nil {
+				return nil, fmt.Errorf("case %s step %s: %w", currentCase.CaseID, step.StepID, err)
+			}
+		}
+		if strings.TrimSpace(step.TestData) == "" {
+			// lifecycle actions such as Disconnect need no test data
+			step.TestDatas = make(map[string]interface{})
+			currentCase.Steps = append(currentCase.Steps, step)
+			continue
+		}
+		if data, ok := parseInlineData(step.TestData, step.StepID); ok {
+			step.TestDatas = data
+			currentCase.Steps = append(currentCase.Steps, step)
+			continue
+		}
 		data, err := p.findTestData(step.TestData, step.StepID)
 		if err != nil {
-			log.Infof("Error finding test data for %s step %s: %v\n", step.TestData, step.StepID, err)
+			dropped := &DroppedStepError{CaseID: currentCase.CaseID, StepID: step.StepID, Err: err}
+			log.Warn(dropped)
+			p.Warnings = append(p.Warnings, dropped)
 			continue
 		}
 		step.TestDatas = data
 		currentCase.Steps = append(currentCase.Steps, step)
 		cases[len(cases)-1] = currentCase
 	}
-	return cases, nil
+	if len(paramSheets) == 0 {
+		return cases, nil
+	}
+	return p.expand(cases, paramSheets)
 }
 
+// expand replaces every case with a parameter sheet with its concrete cases
+func (p *CSVCaseParser) expand(cases []*TestCase, paramSheets map[*TestCase]string) ([]*TestCase, error) {
+	var expanded []*TestCase
+	for _, c := range cases {
+		sheet, ok := paramSheets[c]
+		if !ok {
+			expanded = append(expanded, c)
+			continue
+		}
+		if c.IsSection() {
+			return nil, fmt.Errorf("section %s cannot have a parameter table", c.CaseID)
+		}
+		table, err := LoadParamTable(filepath.Join(filepath.Dir(p.FilePath), sheet+filepath.Ext(p.FilePath)))
+		if err != nil {
+			return nil, fmt.Errorf("case %s: %w", c.CaseID, err)
+		}
+		expanded = append(expanded, table.Expand(c)...)
+	}
+	return expanded, nil
+}
+
+// findTestData returns the row of stepID in the test data sheet named sheetName, next to the case file
 func (p *CSVCaseParser) findTestData(sheetName, stepID string) (map[string]interface{}, error) {
 	if p.testDataCache == nil {
-		p.testDataCache = make(map[string]map[string]interface{})
+		p.testDataCache = make(map[string]map[string]map[string]interface{})
 	}
-	if data, ok := p.testDataCache[stepID]; ok {
-		return data, nil
+	sheet, ok := p.testDataCache[sheetName]
+	if !ok {
+		path := filepath.Join(filepath.Dir(p.FilePath), sheetName+filepath.Ext(p.FilePath))
+		var err error
+		if sheet, err = LoadCSVToMap(path); err != nil {
+			return nil, err
+		}
+		duplicates, err := DuplicateColumns(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(duplicates) > 0 {
+			p.warnf("%s: duplicate columns %s, only the last of each is used", path, strings.Join(duplicates, ", "))
+		}
+		p.testDataCache[sheetName] = sheet
 	}
-	dir := filepath.Dir(p.FilePath)
-	ext := filepath.Ext(p.FilePath)
-	data, err := LoadCSVToMap(filepath.Join(dir, sheetName+ext))
-	if err != nil {
-		return nil, err
+	data, ok := sheet[stepID]
+	if !ok {
+		return nil, fmt.Errorf("no StepId %s in test data %s", stepID, sheetName)
+	}
+	return data, nil
+}
+
+// parseInlineData returns the test data written in the test_data cell as Key=Value pairs separated by ;,
+// such as Times=3;Steps=2 for a control step, false when the cell names a test data sheet instead
+func parseInlineData(cell, stepID string) (map[string]interface{}, bool) {
+	if !strings.Contains(cell, "=") {
+		return nil, false
 	}
-	for k, v := range data {
-		p.testDataCache[k] = v
+	data := map[string]interface{}{"StepId": stepID}
+	for _, pair := range strings.Split(cell, ";") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			data[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
 	}
+	return data, true
+}
+
+func (p *CSVCaseParser) warnf(format string, args ...interface{}) {
+	err := fmt.Errorf(format, args...)
+	log.Warn(err)
+	p.Warnings = append(p.Warnings, err)
+}
+
+func parseOnFailure(cell string) (string, error) {
+	policy := strings.ToLower(strings.TrimSpace(cell))
+	switch policy {
+	case "", OnFailureContinue, OnFailureAbortCase, OnFailureAbortSuite:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown on_failure policy %q", cell)
+}
 
-	return p.testDataCache[stepID], nil
+func parseTags(cell string) []string {
+	return strings.FieldsFunc(cell, func(r rune) bool {
+		return r == ';' || r == ' ' || r == '\t'
+	})
 }
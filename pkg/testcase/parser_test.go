@@ -43,3 +43,91 @@ func TestLoadCSVToMap(t *testing.T) {
 	assert.Equal(t, "new_order_001", data["new_order_001"]["StepId"])
 	assert.Equal(t, "new_order_002", data["new_order_002"]["StepId"])
 }
+
+func TestCSVCaseParserParseLifecycleSteps(t *testing.T) {
+	parser := &CSVCaseParser{FilePath: filepath.Join("testdata", "failover_test_case.csv")}
+	cases, err := parser.Parse()
+
+	assert.NoError(t, err)
+	assert.Len(t, cases, 1)
+	assert.Len(t, cases[0].Steps, 3, "steps without test data are kept")
+	assert.Equal(t, "Disconnect", cases[0].Steps[0].ActionType)
+	assert.NotNil(t, cases[0].Steps[0].TestDatas)
+	assert.Equal(t, "szse_bin_tgw_1@PBU001", cases[0].Steps[2].TestTool)
+}
+
+func TestCSVCaseParserParseLatency(t *testing.T) {
+	parser := &CSVCaseParser{FilePath: filepath.Join("testdata", "latency_test_case.csv")}
+	cases, err := parser.Parse()
+
+	assert.NoError(t, err)
+	assert.Len(t, cases[0].Steps, 3)
+	step := cases[0].Steps[2]
+	assert.Equal(t, "ExpectLatency", step.ActionType)
+	assert.Equal(t, "", step.TestTool)
+	assert.Equal(t, "new_order_002", step.TestDatas["ReceiveStep"])
+	assert.Equal(t, "5", step.TestDatas["WithinMs"])
+}
+
+func TestCSVCaseParserParseOnFailure(t *testing.T) {
+	parser := &CSVCaseParser{FilePath: filepath.Join("testdata", "on_failure_test_case.csv")}
+	cases, err := parser.Parse()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", cases[0].Steps[0].OnFailure)
+	assert.Equal(t, OnFailureAbortCase, cases[0].Steps[1].OnFailure)
+	assert.Equal(t, OnFailureAbortSuite, cases[0].Steps[2].OnFailure)
+
+	_, err = parseOnFailure("retry")
+	assert.Error(t, err)
+}
+
+func TestCSVCaseParserParseInlineData(t *testing.T) {
+	parser := &CSVCaseParser{FilePath: filepath.Join("testdata", "control_test_case.csv")}
+	cases, err := parser.Parse()
+
+	assert.NoError(t, err)
+	assert.Empty(t, parser.Warnings)
+	steps := cases[0].Steps
+	assert.Len(t, steps, 5)
+	assert.Equal(t, "3", steps[0].TestDatas["Times"])
+	assert.Equal(t, "2", steps[0].TestDatas["Steps"])
+	assert.Equal(t, "new_order_001", steps[1].TestDatas["StepId"])
+	assert.Equal(t, "OrdStatus == 8", steps[3].TestDatas["Condition"])
+	assert.Equal(t, "500", steps[4].TestDatas["TimeoutMs"])
+}
+
+func TestCheckTestCasesWarnings(t *testing.T) {
+	cases, warnings, err := CheckTestCases(filepath.Join("testdata", "lint_test_case.csv"))
+
+	assert.NoError(t, err)
+	assert.Len(t, cases[0].Steps, 1, "the steps without test data are dropped")
+	assert.Len(t, warnings, 4)
+	assert.Contains(t, warnings[0].Error(), "duplicate columns UniqueOrderID")
+	assert.Contains(t, warnings[1].Error(), "no StepId new_order_009 in test data risk_200102")
+	assert.Contains(t, warnings[2].Error(), "has 4 columns")
+	assert.Contains(t, warnings[3].Error(), "step new_order_001 dropped")
+
+	_, err = LoadTestCases(filepath.Join("testdata", "lint_test_case.csv"))
+	assert.ErrorContains(t, err, "2 steps dropped", "a run does not go on without the dropped steps")
+	assert.ErrorContains(t, err, "step new_order_001 dropped")
+	assert.NotContains(t, err.Error(), "duplicate columns", "the other warnings do not fail the load")
+	var dropped *DroppedStepError
+	assert.ErrorAs(t, err, &dropped)
+}
+
+func TestLoadTestCasesSampleSuites(t *testing.T) {
+	for _, name := range []string{"risk_test_case.csv", "szse_test_case.csv", "sse_test_case.csv"} {
+		t.Run(name, func(t *testing.T) {
+			cases, err := LoadTestCases(filepath.Join("testdata", name))
+			assert.NoError(t, err)
+			assert.NotEmpty(t, cases)
+		})
+	}
+}
+
+func TestDuplicateColumns(t *testing.T) {
+	duplicates, err := DuplicateColumns(filepath.Join("testdata", "szse_100101.csv"))
+	assert.NoError(t, err)
+	assert.Empty(t, duplicates)
+}
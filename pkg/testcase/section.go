@@ -0,0 +1,77 @@
+package testcase
+
+// A case with one of these IDs holds shared setup or teardown steps instead of being a test case
+const (
+	// SuiteSetup runs once before the first case
+	SuiteSetup = "suite_setup"
+	// SuiteTeardown runs once after the last case, even when a case failed
+	SuiteTeardown = "suite_teardown"
+	// CaseSetup runs before every case
+	CaseSetup = "setup"
+	// CaseTeardown runs after every case, even when one of its steps failed
+	CaseTeardown = "teardown"
+)
+
+// IsSection reports whether the case holds setup or teardown steps rather than being a test case
+func (t *TestCase) IsSection() bool {
+	switch t.CaseID {
+	case SuiteSetup, SuiteTeardown, CaseSetup, CaseTeardown:
+		return true
+	}
+	return false
+}
+
+// Sections are the setup and teardown steps of a suite, a section is nil when the suite has none
+type Sections struct {
+	SuiteSetup    *TestCase
+	SuiteTeardown *TestCase
+	Setup         *TestCase
+	Teardown      *TestCase
+}
+
+// SplitSections separates the setup and teardown sections from the test cases,
+// the steps of a section declared more than once are joined in their order.
+func SplitSections(cases []*TestCase) (Sections, []*TestCase) {
+	var sections Sections
+	var tests []*TestCase
+	for _, c := range cases {
+		var section **TestCase
+		switch c.CaseID {
+		case SuiteSetup:
+			section = &sections.SuiteSetup
+		case SuiteTeardown:
+			section = &sections.SuiteTeardown
+		case CaseSetup:
+			section = &sections.Setup
+		case CaseTeardown:
+			section = &sections.Teardown
+		default:
+			tests = append(tests, c)
+			continue
+		}
+		if *section == nil {
+			*section = c
+			continue
+		}
+		joined := *(*section)
+		joined.Steps = append(append([]TestStep{}, joined.Steps...), c.Steps...)
+		*section = &joined
+	}
+	return sections, tests
+}
+
+// Clone returns a copy of the sections to execute again, without the results of a run
+func (s Sections) Clone() Sections {
+	clone := func(c *TestCase) *TestCase {
+		if c == nil {
+			return nil
+		}
+		return c.Clone()
+	}
+	return Sections{
+		SuiteSetup:    clone(s.SuiteSetup),
+		SuiteTeardown: clone(s.SuiteTeardown),
+		Setup:         clone(s.Setup),
+		Teardown:      clone(s.Teardown),
+	}
+}
@@ -0,0 +1,41 @@
+package testcase
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSections(t *testing.T) {
+	cases, err := LoadTestCases(filepath.Join("testdata", "section_test_case.csv"))
+	assert.NoError(t, err)
+	assert.Len(t, cases, 7)
+
+	sections, tests := SplitSections(cases)
+	assert.Equal(t, []string{"szse_001", "szse_002"}, caseIDs(tests))
+	assert.Equal(t, "logon_001", sections.SuiteSetup.Steps[0].StepID)
+	assert.Equal(t, "logoff_001", sections.SuiteTeardown.Steps[0].StepID)
+	assert.Equal(t, "reset_001", sections.Setup.Steps[0].StepID)
+	assert.Len(t, sections.Teardown.Steps, 2, "repeated sections are joined")
+	assert.Len(t, cases[3].Steps, 1, "joining leaves the parsed case alone")
+
+	clone := sections.Clone()
+	assert.NotSame(t, sections.Setup, clone.Setup)
+	assert.Len(t, clone.Teardown.Steps, 2)
+	assert.Nil(t, Sections{}.Clone().Setup)
+}
+
+func TestFilterKeepsSections(t *testing.T) {
+	cases, err := LoadTestCases(filepath.Join("testdata", "section_test_case.csv"))
+	assert.NoError(t, err)
+
+	selected, err := Filter{CaseID: "szse_002"}.Apply(cases)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"suite_setup", "setup", "teardown", "szse_002", "teardown", "suite_teardown"}, caseIDs(selected))
+
+	selected, err = Filter{FromStep: "szse_001:new_order_002"}.Apply(cases)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"suite_setup", "setup", "szse_001", "teardown", "szse_002", "teardown", "suite_teardown"}, caseIDs(selected))
+	assert.Len(t, selected[2].Steps, 1)
+}
@@ -1,9 +1,21 @@
 package testcase
 
 import (
+	"time"
+
 	"github.com/xinchentechnote/gt-auto/pkg/validate"
 )
 
+// Failure policies of a step, what a failure of the step does to the rest of the run
+const (
+	// OnFailureContinue runs the next step
+	OnFailureContinue = "continue"
+	// OnFailureAbortCase skips the remaining steps of the case, its teardown still runs
+	OnFailureAbortCase = "abort_case"
+	// OnFailureAbortSuite skips the remaining steps and cases, the teardowns still run
+	OnFailureAbortSuite = "abort_suite"
+)
+
 // TestStep represents a single step in a test case.
 type TestStep struct {
 	StepID         string
@@ -14,9 +26,17 @@ type TestStep struct {
 	MsgType        string
 	TestData       string
 	VerifyRequired bool
-	TestDatas      map[string]any
-	Expect         any
-	actual         any
+	// OnFailure is the failure policy of the step, empty for the default of the run
+	OnFailure string
+	TestDatas map[string]any
+	Expect    any
+	actual    any
+	// FrameTime is when the frame of the step was written or read, zero when the simulator does not time frames
+	FrameTime time.Time
+	// Hop and Latency are set on a Receive step correlated with the Send step of another simulator,
+	// Hop names both simulators and Latency is the time between their frames
+	Hop     string
+	Latency time.Duration
 }
 
 // SetActual set receive actual data
@@ -24,6 +44,11 @@ func (t *TestStep) SetActual(actual interface{}) {
 	t.actual = actual
 }
 
+// Actual returns the message received by the step, nil before it ran
+func (t *TestStep) Actual() interface{} {
+	return t.actual
+}
+
 // SetExpect sets the expected value for the step.
 func (t *TestStep) SetExpect(expect interface{}) {
 	t.Expect = expect
@@ -35,32 +60,106 @@ func (t *TestStep) Validate() validate.CompareResult {
 	return result
 }
 
-// StepValidateResult record validate result for step
+// Status of a step result
+const (
+	// StatusPassed the step ran and its validations, if any, passed
+	StatusPassed = "passed"
+	// StatusFailed a validation of the step failed
+	StatusFailed = "failed"
+	// StatusError the action of the step could not be carried out, e.g. its message could not be encoded or written
+	StatusError = "error"
+	// StatusSkipped the step did not run, because an earlier failure aborted the case or suite
+	// or because the control step of its block did not run it
+	StatusSkipped = "skipped"
+)
+
+// StepValidateResult record validate result for step, every step that ran or was skipped has at least one
 type StepValidateResult struct {
 	Index  int
 	StepID string
+	Status string
 	Passed bool
-	Detail validate.CompareResult
+	// Error is why the step errored
+	Error    string
+	Duration time.Duration
+	Detail   validate.CompareResult
 }
 
 // TestCase represents a test case with its steps.
 type TestCase struct {
-	CaseID          string
-	CaseTitle       string
+	CaseID    string
+	CaseTitle string
+	Tags      []string
+	// Params are the values of the parameter table row the case was expanded from
+	Params          map[string]string
 	Steps           []TestStep
 	ValidateResults []StepValidateResult
+	// Variables are captured by Receive steps and substituted in the test data of later steps
+	Variables map[string]string
+	// Setup and Teardown are the runs of the case setup and teardown sections for this case,
+	// nil when the suite has none or before the case ran
+	Setup    *TestCase
+	Teardown *TestCase
+}
+
+// Clone returns a copy of the case to execute again, without the results of a run
+func (t *TestCase) Clone() *TestCase {
+	clone := &TestCase{
+		CaseID:    t.CaseID,
+		CaseTitle: t.CaseTitle,
+		Tags:      t.Tags,
+		Params:    t.Params,
+		Steps:     make([]TestStep, len(t.Steps)),
+	}
+	for i, step := range t.Steps {
+		step.TestDatas = make(map[string]any, len(t.Steps[i].TestDatas))
+		for k, v := range t.Steps[i].TestDatas {
+			step.TestDatas[k] = v
+		}
+		step.Expect = nil
+		step.actual = nil
+		step.FrameTime = time.Time{}
+		step.Hop = ""
+		step.Latency = 0
+		clone.Steps[i] = step
+	}
+	return clone
 }
 
 // AddValidateResult collect validate result for test case
 func (t *TestCase) AddValidateResult(index int, stepID string, result validate.CompareResult) {
+	status := StatusPassed
+	if !result.Equal {
+		status = StatusFailed
+	}
 	t.ValidateResults = append(t.ValidateResults, StepValidateResult{
 		Index:  index,
 		StepID: stepID,
+		Status: status,
 		Passed: result.Equal,
 		Detail: result,
 	})
 }
 
+// AddErrorResult records a step whose action could not be carried out
+func (t *TestCase) AddErrorResult(index int, stepID string, err error) {
+	t.ValidateResults = append(t.ValidateResults, StepValidateResult{
+		Index:  index,
+		StepID: stepID,
+		Status: StatusError,
+		Error:  err.Error(),
+	})
+}
+
+// AddSkippedResult records a step that did not run
+func (t *TestCase) AddSkippedResult(index int, stepID string) {
+	t.ValidateResults = append(t.ValidateResults, StepValidateResult{
+		Index:  index,
+		StepID: stepID,
+		Status: StatusSkipped,
+	})
+}
+
 // CaseParser is an interface for parsing test cases from different formats.
 type CaseParser interface {
 	Parse() ([]*TestCase, error)
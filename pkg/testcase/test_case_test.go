@@ -0,0 +1,26 @@
+package testcase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xinchentechnote/gt-auto/pkg/validate"
+)
+
+func TestCaseClone(t *testing.T) {
+	c := &TestCase{
+		CaseID: "szse_001",
+		Steps:  []TestStep{{StepID: "new_order_001", TestDatas: map[string]any{"ClOrdID": "c0001"}}},
+	}
+	c.Steps[0].SetActual("received")
+	c.Capture("order", "o1")
+	c.AddValidateResult(0, "new_order_001", validate.CompareResult{Equal: true})
+
+	clone := c.Clone()
+	clone.Steps[0].TestDatas["ClOrdID"] = "c0002"
+	assert.Equal(t, "szse_001", clone.CaseID)
+	assert.Equal(t, "c0001", c.Steps[0].TestDatas["ClOrdID"])
+	assert.Nil(t, clone.Steps[0].Actual())
+	assert.Empty(t, clone.ValidateResults)
+	assert.Empty(t, clone.Variables)
+}
@@ -49,3 +49,29 @@ func LoadCSVToMap(filePath string) (map[string]map[string]interface{}, error) {
 
 	return records, nil
 }
+
+// DuplicateColumns returns the columns appearing more than once in the header of a CSV file,
+// LoadCSVToMap keeps the value of the last of them
+func DuplicateColumns(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]int, len(headers))
+	var duplicates []string
+	for _, header := range headers {
+		seen[header]++
+		if seen[header] == 2 {
+			duplicates = append(duplicates, header)
+		}
+	}
+	return duplicates, nil
+}
@@ -0,0 +1,102 @@
+package testcase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Wildcard as the value of a Receive field accepts any value
+const Wildcard = "*"
+
+// variablePattern matches a ${name} reference
+var variablePattern = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// CaptureName returns name when v is exactly ${name}
+func CaptureName(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	m := variablePattern.FindStringSubmatch(s)
+	if m == nil || m[0] != s {
+		return "", false
+	}
+	return m[1], true
+}
+
+// Expectations splits the test data of a Receive step.
+// A Wildcard field or a ${name} field is left out of the expected message: both accept any value,
+// and the value received for a ${name} field is captured under name.
+// It returns the expected fields, the fields to ignore when validating and the captures by field.
+func Expectations(data map[string]interface{}) (map[string]interface{}, []string, map[string]string) {
+	expected := make(map[string]interface{}, len(data))
+	var ignored []string
+	captures := make(map[string]string)
+	for k, v := range data {
+		if v == Wildcard {
+			ignored = append(ignored, k)
+			continue
+		}
+		if name, ok := CaptureName(v); ok {
+			ignored = append(ignored, k)
+			captures[k] = name
+			continue
+		}
+		expected[k] = v
+	}
+	return expected, ignored, captures
+}
+
+// Substitute returns data with every ${name} replaced by the variable captured by an earlier step,
+// references to unknown variables are left as-is.
+func (t *TestCase) Substitute(data map[string]interface{}) map[string]interface{} {
+	return SubstituteVariables(data, t.Variables)
+}
+
+// SubstituteVariables returns data with every ${name} in its string values replaced by variables[name],
+// references to unknown variables are left as-is.
+func SubstituteVariables(data map[string]interface{}, variables map[string]string) map[string]interface{} {
+	if len(variables) == 0 {
+		return data
+	}
+	result := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		s, ok := v.(string)
+		if !ok || !strings.Contains(s, "${") {
+			result[k] = v
+			continue
+		}
+		result[k] = variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+			if value, ok := variables[ref[2:len(ref)-1]]; ok {
+				return value
+			}
+			return ref
+		})
+	}
+	return result
+}
+
+// Capture records the value of a variable for the later steps of the case
+func (t *TestCase) Capture(name string, value interface{}) {
+	if t.Variables == nil {
+		t.Variables = make(map[string]string)
+	}
+	t.Variables[name] = fmt.Sprint(value)
+}
+
+// LookupField returns the value of field in a JSON-like map, searching nested objects
+// when it is not a top level key
+func LookupField(fields map[string]interface{}, field string) (interface{}, bool) {
+	if v, ok := fields[field]; ok {
+		return v, true
+	}
+	for _, v := range fields {
+		if nested, ok := v.(map[string]interface{}); ok {
+			if found, ok := LookupField(nested, field); ok {
+				return found, true
+			}
+		}
+	}
+	return nil, false
+}
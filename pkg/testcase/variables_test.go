@@ -0,0 +1,44 @@
+package testcase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpectations(t *testing.T) {
+	expected, ignored, captures := Expectations(map[string]interface{}{
+		"ClOrdID":      "c0001",
+		"TransactTime": Wildcard,
+		"OrderID":      "${order}",
+		"Text":         "id ${order}",
+	})
+	assert.Equal(t, map[string]interface{}{"ClOrdID": "c0001", "Text": "id ${order}"}, expected)
+	assert.ElementsMatch(t, []string{"TransactTime", "OrderID"}, ignored)
+	assert.Equal(t, map[string]string{"OrderID": "order"}, captures)
+}
+
+func TestSubstitute(t *testing.T) {
+	c := &TestCase{}
+	data := map[string]interface{}{"OrderID": "${order}", "Text": "cancel ${order} ${other}", "OrderQty": 100}
+	assert.Equal(t, data, c.Substitute(data))
+
+	c.Capture("order", 778)
+	assert.Equal(t, map[string]interface{}{
+		"OrderID":  "778",
+		"Text":     "cancel 778 ${other}",
+		"OrderQty": 100,
+	}, c.Substitute(data))
+}
+
+func TestLookupField(t *testing.T) {
+	fields := map[string]interface{}{
+		"ClOrdID":    "c0001",
+		"ApplExtend": map[string]interface{}{"OrderID": "o1"},
+	}
+	v, ok := LookupField(fields, "OrderID")
+	assert.True(t, ok)
+	assert.Equal(t, "o1", v)
+	_, ok = LookupField(fields, "Missing")
+	assert.False(t, ok)
+}
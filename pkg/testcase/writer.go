@@ -0,0 +1,124 @@
+package testcase
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// caseHeader is the header of a CSV case file
+var caseHeader = []string{"case_id", "case_title", "step_id", "sleep_ms", "step_desc", "action_type",
+	"verify_required", "test_tool", "msg_type", "test_data", "tags", "params", "on_failure"}
+
+// WriteCSVCases writes cases to the CSV case file at path and their test data to one sheet
+// per TestData name next to it, the layout read by CSVCaseParser.
+func WriteCSVCases(path string, cases []*TestCase) error {
+	var rows [][]string
+	sheets := make(map[string][]map[string]interface{})
+	var sheetNames []string
+	for _, c := range cases {
+		for i, step := range c.Steps {
+			caseID, caseTitle, tags := "", "", ""
+			if i == 0 {
+				caseID, caseTitle, tags = c.CaseID, c.CaseTitle, strings.Join(c.Tags, ";")
+			}
+			verify := "N"
+			if step.VerifyRequired {
+				verify = "Y"
+			}
+			rows = append(rows, []string{caseID, caseTitle, step.StepID, step.SleepMs, step.StepDesc,
+				step.ActionType, verify, step.TestTool, step.MsgType, step.TestData, tags, "", step.OnFailure})
+			if step.TestData == "" {
+				continue
+			}
+			if _, ok := sheets[step.TestData]; !ok {
+				sheetNames = append(sheetNames, step.TestData)
+			}
+			data := make(map[string]interface{}, len(step.TestDatas)+1)
+			for k, v := range step.TestDatas {
+				data[k] = v
+			}
+			data["StepId"] = step.StepID
+			sheets[step.TestData] = append(sheets[step.TestData], data)
+		}
+	}
+	if err := writeCSV(path, caseHeader, rows); err != nil {
+		return err
+	}
+	dir, ext := filepath.Dir(path), filepath.Ext(path)
+	for _, name := range sheetNames {
+		if err := writeDataSheet(filepath.Join(dir, name+ext), sheets[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDataSheet writes records keyed by StepId, with the union of their fields as columns
+func writeDataSheet(path string, records []map[string]interface{}) error {
+	columns := map[string]bool{}
+	for _, record := range records {
+		for k := range record {
+			columns[k] = true
+		}
+	}
+	delete(columns, "StepId")
+	header := []string{"StepId"}
+	for k := range columns {
+		header = append(header, k)
+	}
+	sort.Strings(header[1:])
+	rows := make([][]string, 0, len(records))
+	for _, record := range records {
+		row := make([]string, len(header))
+		for i, column := range header {
+			cell, err := formatCell(record[column])
+			if err != nil {
+				return fmt.Errorf("%s: %s: %w", path, column, err)
+			}
+			row[i] = cell
+		}
+		rows = append(rows, row)
+	}
+	return writeCSV(path, header, rows)
+}
+
+// formatCell writes nested values as JSON, which the codecs decode from the cell again
+func formatCell(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(value)
+		return string(data), err
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
+
+func writeCSV(path string, header []string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return file.Close()
+}
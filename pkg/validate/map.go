@@ -0,0 +1,88 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+var mapType = reflect.TypeOf(map[string]interface{}{})
+
+// asMap returns v as a JSON-like map when it is one, or a pointer to one
+func asMap(v interface{}) (map[string]interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Map || !rv.Type().ConvertibleTo(mapType) {
+		return nil, false
+	}
+	return rv.Convert(mapType).Interface().(map[string]interface{}), true
+}
+
+// CompareMap compares two JSON-like maps key by key and returns a CompareResult.
+// Scalars are compared by their text form, so "100" from a CSV cell equals the JSON number 100.
+func CompareMap(a, b map[string]interface{}) CompareResult {
+	diffs := compareValue("", a, b, nil)
+	return CompareResult{
+		Equal: len(diffs) == 0,
+		Diffs: diffs,
+	}
+}
+
+func compareValue(path string, a, b interface{}, diffs []Diff) []Diff {
+	if ma, ok := a.(map[string]interface{}); ok {
+		if mb, ok := b.(map[string]interface{}); ok {
+			return compareMapValue(path, ma, mb, diffs)
+		}
+	}
+	if la, ok := a.([]interface{}); ok {
+		if lb, ok := b.([]interface{}); ok {
+			if len(la) != len(lb) {
+				return append(diffs, Diff{Path: path + ".len", Expect: len(la), Actual: len(lb)})
+			}
+			for i := range la {
+				diffs = compareValue(fmt.Sprintf("%s[%d]", path, i), la[i], lb[i], diffs)
+			}
+			return diffs
+		}
+	}
+	if fmt.Sprint(a) != fmt.Sprint(b) || (a == nil) != (b == nil) {
+		diffs = append(diffs, Diff{Path: path, Expect: formatMapValue(a), Actual: formatMapValue(b)})
+	}
+	return diffs
+}
+
+func compareMapValue(path string, a, b map[string]interface{}, diffs []Diff) []Diff {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		keyPath := k
+		if path != "" {
+			keyPath = path + "." + k
+		}
+		va, okA := a[k]
+		vb, okB := b[k]
+		if !okA || !okB {
+			diffs = append(diffs, Diff{Path: keyPath, Expect: formatMapValue(va), Actual: formatMapValue(vb)})
+			continue
+		}
+		diffs = compareValue(keyPath, va, vb, diffs)
+	}
+	return diffs
+}
+
+func formatMapValue(v interface{}) interface{} {
+	if v == nil {
+		return "<nil>"
+	}
+	return v
+}
@@ -0,0 +1,42 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonMessage map[string]interface{}
+
+func TestCompareMap(t *testing.T) {
+	expect := jsonMessage{
+		"ClOrdID": "c0001",
+		"Price":   "100",
+		"Legs":    []interface{}{map[string]interface{}{"Side": "1"}},
+	}
+	actual := jsonMessage{
+		"ClOrdID": "c0001",
+		"Price":   json.Number("100"),
+		"Legs":    []interface{}{map[string]interface{}{"Side": json.Number("1")}},
+	}
+	result := CompareStruct(&expect, &actual)
+	assert.True(t, result.Equal, result.Diffs)
+
+	actual["Price"] = json.Number("101")
+	actual["Legs"] = []interface{}{map[string]interface{}{"Side": "2"}}
+	actual["OrderID"] = "o0001"
+	delete(actual, "ClOrdID")
+	result = CompareStruct(&expect, &actual)
+	assert.False(t, result.Equal)
+	assert.Equal(t, []Diff{
+		{Path: "ClOrdID", Expect: "c0001", Actual: "<nil>"},
+		{Path: "Legs[0].Side", Expect: "1", Actual: "2"},
+		{Path: "OrderID", Expect: "<nil>", Actual: "o0001"},
+		{Path: "Price", Expect: "100", Actual: json.Number("101")},
+	}, result.Diffs)
+
+	result = CompareStruct(expect, nil)
+	assert.False(t, result.Equal)
+	assert.Len(t, result.Diffs, 3)
+}
@@ -0,0 +1,136 @@
+package validate
+
+import (
+	"bytes"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CompareProto compares two protobuf messages field by field and returns a CompareResult.
+// Paths are the dotted proto field names, list elements and map entries are indexed by [].
+func CompareProto(a, b proto.Message) CompareResult {
+	var diffs []Diff
+	switch {
+	case a == nil || b == nil:
+		if a != b {
+			diffs = append(diffs, Diff{Path: "", Expect: protoOrNil(a), Actual: protoOrNil(b)})
+		}
+	default:
+		diffs = compareProtoMessage("", a.ProtoReflect(), b.ProtoReflect(), diffs)
+	}
+	return CompareResult{
+		Equal: len(diffs) == 0,
+		Diffs: diffs,
+	}
+}
+
+func protoOrNil(m proto.Message) interface{} {
+	if m == nil {
+		return "<nil>"
+	}
+	return m.ProtoReflect().Descriptor().FullName()
+}
+
+func compareProtoMessage(path string, a, b protoreflect.Message, diffs []Diff) []Diff {
+	if a.Descriptor().FullName() != b.Descriptor().FullName() {
+		return append(diffs, Diff{Path: path, Expect: a.Descriptor().FullName(), Actual: b.Descriptor().FullName()})
+	}
+	fields := a.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		fieldPath := string(fd.Name())
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+		switch {
+		case fd.IsList():
+			diffs = compareProtoList(fieldPath, fd, a.Get(fd).List(), b.Get(fd).List(), diffs)
+		case fd.IsMap():
+			diffs = compareProtoMap(fieldPath, fd, a.Get(fd).Map(), b.Get(fd).Map(), diffs)
+		case fd.Message() != nil:
+			if a.Has(fd) || b.Has(fd) {
+				diffs = compareProtoMessage(fieldPath, a.Get(fd).Message(), b.Get(fd).Message(), diffs)
+			}
+		default:
+			diffs = compareProtoScalar(fieldPath, fd, a.Get(fd), b.Get(fd), diffs)
+		}
+	}
+	return diffs
+}
+
+func compareProtoList(path string, fd protoreflect.FieldDescriptor, a, b protoreflect.List, diffs []Diff) []Diff {
+	if a.Len() != b.Len() {
+		return append(diffs, Diff{Path: path + ".len", Expect: a.Len(), Actual: b.Len()})
+	}
+	for i := 0; i < a.Len(); i++ {
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		if fd.Message() != nil {
+			diffs = compareProtoMessage(elemPath, a.Get(i).Message(), b.Get(i).Message(), diffs)
+		} else {
+			diffs = compareProtoScalar(elemPath, fd, a.Get(i), b.Get(i), diffs)
+		}
+	}
+	return diffs
+}
+
+func compareProtoMap(path string, fd protoreflect.FieldDescriptor, a, b protoreflect.Map, diffs []Diff) []Diff {
+	keys := make([]protoreflect.MapKey, 0, a.Len()+b.Len())
+	a.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	b.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		if !a.Has(k) {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	for _, k := range keys {
+		entryPath := fmt.Sprintf("%s[%v]", path, k.Interface())
+		if !a.Has(k) || !b.Has(k) {
+			diffs = append(diffs, Diff{Path: entryPath, Expect: protoMapEntry(fd, a, k), Actual: protoMapEntry(fd, b, k)})
+			continue
+		}
+		if fd.MapValue().Message() != nil {
+			diffs = compareProtoMessage(entryPath, a.Get(k).Message(), b.Get(k).Message(), diffs)
+		} else {
+			diffs = compareProtoScalar(entryPath, fd.MapValue(), a.Get(k), b.Get(k), diffs)
+		}
+	}
+	return diffs
+}
+
+func protoMapEntry(fd protoreflect.FieldDescriptor, m protoreflect.Map, k protoreflect.MapKey) interface{} {
+	if !m.Has(k) {
+		return "<nil>"
+	}
+	if fd.MapValue().Message() != nil {
+		return m.Get(k).Message().Interface()
+	}
+	return formatProtoScalar(fd.MapValue(), m.Get(k))
+}
+
+func compareProtoScalar(path string, fd protoreflect.FieldDescriptor, a, b protoreflect.Value, diffs []Diff) []Diff {
+	var equal bool
+	if fd.Kind() == protoreflect.BytesKind {
+		equal = bytes.Equal(a.Bytes(), b.Bytes())
+	} else {
+		equal = a.Interface() == b.Interface()
+	}
+	if !equal {
+		diffs = append(diffs, Diff{Path: path, Expect: formatProtoScalar(fd, a), Actual: formatProtoScalar(fd, b)})
+	}
+	return diffs
+}
+
+// formatProtoScalar shows enum values by name when the number is defined
+func formatProtoScalar(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	if fd.Kind() == protoreflect.EnumKind {
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return ev.Name()
+		}
+	}
+	return v.Interface()
+}
@@ -0,0 +1,49 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestCompareProto(t *testing.T) {
+	field := func(name string, number int32, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  label.Enum(),
+		}
+	}
+	expect := &descriptorpb.DescriptorProto{
+		Name: proto.String("NewOrder"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			field("cl_ord_id", 1, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+			field("price", 2, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+		},
+	}
+
+	result := CompareStruct(expect, proto.Clone(expect))
+	assert.True(t, result.Equal)
+	assert.Empty(t, result.Diffs)
+
+	actual := proto.Clone(expect).(*descriptorpb.DescriptorProto)
+	actual.Field[1].Number = proto.Int32(3)
+	actual.Field[1].Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	result = CompareStruct(expect, actual)
+	assert.False(t, result.Equal)
+	assert.Equal(t, []Diff{
+		{Path: "field[1].number", Expect: int32(2), Actual: int32(3)},
+		{Path: "field[1].label", Expect: protoreflect.Name("LABEL_OPTIONAL"), Actual: protoreflect.Name("LABEL_REPEATED")},
+	}, result.Diffs)
+
+	actual.Field = actual.Field[:1]
+	result = CompareStruct(expect, actual)
+	assert.Equal(t, []Diff{{Path: "field.len", Expect: 2, Actual: 1}}, result.Diffs)
+
+	result = CompareStruct(expect, nil)
+	assert.False(t, result.Equal)
+	assert.Len(t, result.Diffs, 1)
+}
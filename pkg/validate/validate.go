@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/olekukonko/tablewriter"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
 )
 
 // Diff represents a difference between two structs.
@@ -58,7 +60,17 @@ func formatValue(v reflect.Value) interface{} {
 }
 
 // CompareStruct compares two structs and returns a CompareResult.
+// Protobuf messages are compared field by field with CompareProto,
+// JSON-like maps key by key with CompareMap.
 func CompareStruct(a, b interface{}) CompareResult {
+	if pa, ok := a.(proto.Message); ok {
+		pb, _ := b.(proto.Message)
+		return CompareProto(pa, pb)
+	}
+	if ma, ok := asMap(a); ok {
+		mb, _ := asMap(b)
+		return CompareMap(ma, mb)
+	}
 	r := &DiffReporter{}
 	cmp.Diff(a, b, cmp.Reporter(r))
 	return CompareResult{
@@ -87,3 +99,49 @@ func PrintCompareResult(result CompareResult) {
 		table.Render()
 	}
 }
+
+// Ignore returns the result without the differences found in fields. List indexes aside, a field
+// matches a Diff path it equals or is a parent of, and a bare field name also matches the last
+// segment of a path, so TransactTime ignores ApplExtend.TransactTime but Header ignores nothing below Body.
+func (r CompareResult) Ignore(fields ...string) CompareResult {
+	if len(fields) == 0 {
+		return r
+	}
+	ignored := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		ignored[f] = true
+	}
+	var diffs []Diff
+	for _, diff := range r.Diffs {
+		if !pathHasField(diff.Path, ignored) {
+			diffs = append(diffs, diff)
+		}
+	}
+	r.Diffs = diffs
+	r.Equal = len(diffs) == 0
+	return r
+}
+
+func pathHasField(path string, fields map[string]bool) bool {
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		if j := strings.IndexByte(segment, '['); j >= 0 {
+			segments[i] = segment[:j]
+		}
+	}
+	if fields[segments[len(segments)-1]] {
+		return true
+	}
+	for i := range segments {
+		if fields[strings.Join(segments[:i+1], ".")] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTimeField reports whether a field holds a timestamp, its name ends with Time
+// as in TransactTime, unlike TimeInForce.
+func IsTimeField(name string) bool {
+	return strings.HasSuffix(name, "Time")
+}
@@ -58,3 +58,35 @@ type Address struct {
 	City    string
 	Country string
 }
+
+func TestCompareResultIgnore(t *testing.T) {
+	result := CompareResult{Diffs: []Diff{
+		{Path: "TransactTime"},
+		{Path: "ApplExtend.OrderID"},
+		{Path: "Parties[1].PartyID"},
+		{Path: "Price"},
+	}}
+	ignored := result.Ignore("TransactTime", "OrderID", "Parties")
+	assert.False(t, ignored.Equal)
+	assert.Equal(t, []Diff{{Path: "Price"}}, ignored.Diffs)
+	assert.True(t, result.Ignore("TransactTime", "OrderID", "Parties", "Price").Equal)
+}
+
+func TestCompareResultIgnorePaths(t *testing.T) {
+	result := CompareResult{Diffs: []Diff{
+		{Path: "ApplExtend.OrderID"},
+		{Path: "ApplExtend.Parties[0].PartyID"},
+		{Path: "Body.ApplExtend.Price"},
+	}}
+	assert.Equal(t, []Diff{{Path: "Body.ApplExtend.Price"}}, result.Ignore("ApplExtend").Diffs)
+	assert.Equal(t, []Diff{{Path: "ApplExtend.OrderID"}, {Path: "Body.ApplExtend.Price"}},
+		result.Ignore("ApplExtend.Parties").Diffs)
+	assert.Equal(t, result.Diffs, result.Ignore("Parties", "Body.OrderID").Diffs)
+}
+
+func TestIsTimeField(t *testing.T) {
+	assert.True(t, IsTimeField("TransactTime"))
+	assert.True(t, IsTimeField("OrigTime"))
+	assert.False(t, IsTimeField("TimeInForce"))
+	assert.False(t, IsTimeField("ClOrdID"))
+}